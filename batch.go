@@ -0,0 +1,262 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// OpKind is a kind of Tree's operation
+type OpKind byte
+
+const (
+	// OpInsert - inserting of key to Tree
+	OpInsert OpKind = iota + 1
+	// OpDelete - deleting of key from Tree
+	OpDelete
+	// OpPut - saving of key with value to Map
+	OpPut
+)
+
+// Op is the structure of Tree's operation which changed nodes of Batch.
+// Kind is a kind of operation, Key is a key of operation and Value is a value saved by OpPut
+type Op[V any] struct {
+	Kind  OpKind
+	Key   V
+	Value []byte
+}
+
+// Batch is the structure of changes which are applied to storage together.
+// Ops are operations which made these changes, Writes are nodes which should be written, Deletes are names
+// of nodes which should be deleted (a Node allocated by the same batch can be absent in storage),
+// Meta is new metadata of Tree (nil if metadata isn't changed) and Refs are new amounts of references
+// to shared nodes (see RefStorage)
+type Batch[V any] struct {
+	Ops     []Op[V]
+	Writes  []*Node[V]
+	Deletes []string
+	Meta    *Meta
+	Refs    map[string]int
+}
+
+// BatchStorage is a NodeStorage which applies Batch atomically:
+// after a crash storage has all changes of Batch or none of them.
+// If storage doesn't implement it, changes of Batch are applied one by one
+type BatchStorage[V any] interface {
+	NodeStorage[V]
+	Apply(b Batch[V]) error
+}
+
+// batchStorage - internal NodeStorage which keeps changes in memory over base storage until they are applied
+type batchStorage[V any] struct {
+	base      NodeStorage[V]
+	ops       []Op[V]
+	nodes     map[string]*Node[V] // changed nodes, nil value means deleted Node
+	order     []string            // names of changed nodes in order of the first change
+	allocated []string            // names allocated in base storage by batch
+	meta      *Meta
+}
+
+// newBatchStorage - internal function for creating empty batch over storage base
+func newBatchStorage[V any](base NodeStorage[V]) *batchStorage[V] {
+	return &batchStorage[V]{
+		base:  base,
+		nodes: make(map[string]*Node[V]),
+	}
+}
+
+// Name - returns name of base storage
+func (bs *batchStorage[V]) Name() string {
+	return bs.base.Name()
+}
+
+// AllocName - allocates name in base storage
+func (bs *batchStorage[V]) AllocName() (string, error) {
+	name, err := bs.base.AllocName()
+	if err != nil {
+		return "", err
+	}
+	bs.allocated = append(bs.allocated, name)
+
+	return name, nil
+}
+
+// Read - reads changed Node from batch or Node from base storage
+func (bs *batchStorage[V]) Read(name string) (*Node[V], error) {
+	n, ok := bs.nodes[name]
+	if !ok {
+		return bs.base.Read(name)
+	}
+	if n == nil {
+		return nil, fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+
+	return n.clone(), nil
+}
+
+// Write - keeps copy of Node in batch
+func (bs *batchStorage[V]) Write(n *Node[V]) error {
+	bs.change(n.Name, n.clone())
+
+	return nil
+}
+
+// Delete - marks Node as deleted in batch
+func (bs *batchStorage[V]) Delete(name string) error {
+	if n, ok := bs.nodes[name]; ok && n == nil {
+		return fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+	bs.change(name, nil)
+
+	return nil
+}
+
+// ReadMeta - reads metadata from batch or from base storage
+func (bs *batchStorage[V]) ReadMeta() (Meta, error) {
+	if bs.meta != nil {
+		return *bs.meta, nil
+	}
+	if ms, ok := bs.base.(MetaStorage[V]); ok {
+		return ms.ReadMeta()
+	}
+
+	return Meta{}, fs.ErrNotExist
+}
+
+// WriteMeta - keeps metadata in batch
+func (bs *batchStorage[V]) WriteMeta(m Meta) error {
+	bs.meta = &m
+
+	return nil
+}
+
+// Apply - keeps changes of other batch in batch, so batches can be nested
+func (bs *batchStorage[V]) Apply(b Batch[V]) error {
+	bs.ops = append(bs.ops, b.Ops...)
+	for _, n := range b.Writes {
+		bs.change(n.Name, n)
+	}
+	for _, name := range b.Deletes {
+		bs.change(name, nil)
+	}
+	if b.Meta != nil {
+		bs.meta = b.Meta
+	}
+
+	return nil
+}
+
+// discard - internal function for releasing names allocated by batch which won't be applied
+func (bs *batchStorage[V]) discard() {
+	for _, name := range bs.allocated {
+		bs.base.Delete(name)
+	}
+}
+
+// change - internal function for saving change of Node in batch
+func (bs *batchStorage[V]) change(name string, n *Node[V]) {
+	if _, ok := bs.nodes[name]; !ok {
+		bs.order = append(bs.order, name)
+	}
+	bs.nodes[name] = n
+}
+
+// batch - internal function: returns collected changes
+func (bs *batchStorage[V]) batch() Batch[V] {
+	b := Batch[V]{Ops: bs.ops, Meta: bs.meta}
+	for _, name := range bs.order {
+		if n := bs.nodes[name]; n != nil {
+			b.Writes = append(b.Writes, n)
+		} else {
+			b.Deletes = append(b.Deletes, name)
+		}
+	}
+
+	return b
+}
+
+// applyBatch - internal function for applying changes to storage s.
+// Changes are atomic if storage implements BatchStorage
+func applyBatch[V any](s NodeStorage[V], b Batch[V]) error {
+	if bs, ok := s.(BatchStorage[V]); ok {
+		return bs.Apply(b)
+	}
+
+	return writeBatch(s, b)
+}
+
+// writeBatch - internal function for applying changes to storage s one by one.
+// It can be repeated: deleting of absent Node isn't an error
+func writeBatch[V any](s NodeStorage[V], b Batch[V]) error {
+	for _, n := range b.Writes {
+		if err := s.Write(n); err != nil {
+			return err
+		}
+	}
+	for _, name := range b.Deletes {
+		if err := s.Delete(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if ms, ok := s.(MetaStorage[V]); ok && b.Meta != nil {
+		return ms.WriteMeta(*b.Meta)
+	}
+
+	return nil
+}
+
+// update - internal function: runs operation op of Tree by fn over batch and applies all changed nodes
+// and metadata to storage together. If fn fails, storage and Tree aren't changed
+func (t *Tree[V]) update(op Op[V], fn func(t *Tree[V]) error) error {
+	b := newBatchStorage[V](t.storage)
+	b.ops = append(b.ops, op)
+	view := t.withStorage(b)
+
+	if err := fn(view); err != nil {
+		b.discard()
+		return err
+	}
+
+	return t.commit(b, view.meta)
+}
+
+// commit - internal function for applying changes of batch b to storage together with new metadata meta.
+// If Tree shares nodes with clones or snapshots, shared nodes are copied instead of changing
+func (t *Tree[V]) commit(b *batchStorage[V], meta Meta) error {
+	batch := b.batch()
+	if t.refs != nil {
+		t.refs.mu.Lock()
+		defer t.refs.mu.Unlock()
+
+		if t.refs.shared() {
+			var err error
+			if batch, err = t.copyOnWrite(b, batch); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := applyBatch(t.storage, batch); err != nil {
+		return err
+	}
+	t.meta = meta
+	t.version++
+
+	if t.refs != nil {
+		t.refs.update(batch.Refs, batch.Deletes)
+	}
+
+	return nil
+}
+
+// withStorage - internal function: returns Tree with the same parameters, root and metadata which works with storage s.
+// Returned Tree doesn't count references: its changes are counted when they are committed by t
+func (t *Tree[V]) withStorage(s NodeStorage[V]) *Tree[V] {
+	return &Tree[V]{
+		storage: s,
+		t:       t.t,
+		compare: t.compare,
+		meta:    t.meta,
+		root:    t.root,
+	}
+}
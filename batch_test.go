@@ -0,0 +1,70 @@
+package btree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTree_update_failed(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	s := &testStorage{MemoryStorage: ms}
+	t, _ := NewTree[int](2, s)
+	for i := 0; i < 100; i++ {
+		t.Insert(i)
+	}
+	height, nodeCount := t.Height(), t.NodeCount()
+
+	// deleting fails in the middle of the path, when some nodes are already changed
+	for _, k := range []int{0, 50, 99} {
+		s.reads, s.failAfter = 0, t.Height()+2
+		if err := t.Delete(k); err == nil {
+			t1.Fatalf("Delete(%d) expected error", k)
+		}
+	}
+	s.failAfter = 0
+
+	if t.Len() != 100 || t.Height() != height || t.NodeCount() != nodeCount {
+		t1.Errorf("Meta got = %v, %v, %v", t.Len(), t.Height(), t.NodeCount())
+	}
+	checkTreeBalance(t, t1)
+	for i := 0; i < 100; i++ {
+		if ok, _ := t.Exists(i); !ok {
+			t1.Errorf("Exists(%d) got = false, want true", i)
+		}
+	}
+}
+
+func TestTree_update_without_BatchStorage(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, NodeStorage[int](plainStorage{ms}))
+
+	r := rand.New(rand.NewSource(1))
+	for _, k := range r.Perm(300) {
+		t.Insert(k)
+	}
+	for _, k := range r.Perm(300)[:200] {
+		if err := t.Delete(k); err != nil {
+			t1.Fatalf("Delete(%d) error = %v", k, err)
+		}
+	}
+	checkTreeBalance(t, t1)
+
+	// changes are applied to storage one by one
+	t2, _ := NewTree[int](2, NodeStorage[int](plainStorage{ms}))
+	if t2.Len() != 100 || t2.NodeCount() != t.NodeCount() {
+		t1.Errorf("Meta got = %v, %v, want %v, %v", t2.Len(), t2.NodeCount(), 100, t.NodeCount())
+	}
+	if len(ms.nodes) != t.NodeCount() {
+		t1.Errorf("amount of nodes in storage got = %v, want %v", len(ms.nodes), t.NodeCount())
+	}
+}
+
+// collectBatch - returns changes of storage made by fn without applying them
+func collectBatch(t *Tree[int], fn func(t *Tree[int]) error) Batch[int] {
+	b := newBatchStorage[int](t.storage)
+	if err := fn(t.withStorage(b)); err != nil {
+		panic(err)
+	}
+
+	return b.batch()
+}
@@ -0,0 +1,232 @@
+package btree
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+
+	"golang.org/x/exp/constraints"
+)
+
+// DefaultFillFactor - is a share of max amount of keys which BuildFromSorted puts to every Node if other share isn't set
+const DefaultFillFactor = 1.0
+
+// BuildOptions is the structure of BuildFromSorted's parameters.
+// FillFactor is a share of max amount of keys (2t-1) which is put to every Node. It should be greater than 0
+// and not greater than 1 (DefaultFillFactor if it's zero). Node doesn't get less than t-1 keys
+type BuildOptions struct {
+	FillFactor float64
+}
+
+// buildLevel - internal structure of keys and children of one level of built Tree which aren't put to nodes yet.
+// keys[i] is between children[i] and children[i+1], leaves' level has only keys
+type buildLevel[V any] struct {
+	keys     []V
+	children []string
+	counts   []int
+}
+
+// builder - internal structure for building Tree from leaves to root
+type builder[V any] struct {
+	tree   *Tree[V]         // Tree over batch: built nodes are kept in memory until they are applied together
+	fill   int              // amount of keys in built Node
+	levels []*buildLevel[V] // levels from leaves to root
+}
+
+// BuildFromSorted is a function for creating tree from sorted keys. Tree is built from leaves to root:
+// keys aren't inserted one by one, all nodes and metadata are written to storage by one batch
+// - type V should be `ordered type` (`int`, `string`, `float` etc.)
+// - param t is a min degree of b-tree. It can't be less than 2
+// - param s is a storage of empty tree (e.g. new storage)
+// - param seq returns keys in ascending order without duplicates, else building fails
+// - param opts is a fill factor of nodes
+func BuildFromSorted[V constraints.Ordered](t int, s NodeStorage[V], seq iter.Seq[V], opts BuildOptions) (*Tree[V], error) {
+	return BuildFromSortedFunc[V](t, cmp.Compare[V], s, seq, opts)
+}
+
+// BuildFromSortedFunc is a function for creating tree from keys sorted by custom comparator (see BuildFromSorted).
+// If building fails, allocated names are deleted and tree in storage stays empty
+// - param compare returns a negative number when a < b, a positive number when a > b and zero when a == b
+func BuildFromSortedFunc[V any](t int, compare func(a, b V) int, s NodeStorage[V], seq iter.Seq[V], opts BuildOptions) (*Tree[V], error) {
+	if opts.FillFactor == 0 {
+		opts.FillFactor = DefaultFillFactor
+	}
+	if opts.FillFactor <= 0 || opts.FillFactor > 1 {
+		return nil, fmt.Errorf("fill factor %v should be greater than 0 and not greater than 1", opts.FillFactor)
+	}
+
+	tree, err := NewTreeFunc[V](t, compare, s)
+	if err != nil {
+		return nil, err
+	}
+	if tree.meta.Len > 0 {
+		return nil, errors.New("tree in storage isn't empty")
+	}
+
+	bs := newBatchStorage[V](tree.storage)
+	b := &builder[V]{
+		tree:   tree.withStorage(bs),
+		fill:   max(t-1, int(math.Round(opts.FillFactor*float64(tree.maxKeysLength())))),
+		levels: []*buildLevel[V]{{}},
+	}
+	if err = b.build(seq); err != nil {
+		bs.discard()
+		return nil, err
+	}
+	if err = tree.commit(bs, b.tree.meta); err != nil {
+		bs.discard()
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// build - internal function for building Tree from keys of seq and saving its metadata to batch
+func (b *builder[V]) build(seq iter.Seq[V]) error {
+	t := b.tree
+	t.meta = Meta{}
+
+	var prev V
+	for k := range seq {
+		if t.meta.Len > 0 && t.compare(prev, k) >= 0 {
+			return fmt.Errorf("keys aren't sorted: key %v after %v", k, prev)
+		}
+		prev = k
+		t.meta.Len++
+
+		if err := b.addKey(k); err != nil {
+			return err
+		}
+	}
+
+	if err := b.finish(); err != nil {
+		return err
+	}
+	t.meta.Height = len(b.levels)
+
+	return t.writeMeta()
+}
+
+// addKey - internal function for adding key to leaves' level. Leaf is written when there are enough keys after it
+// for one more leaf, so the last leaf gets at least t-1 keys
+func (b *builder[V]) addKey(k V) error {
+	l := b.levels[0]
+	l.keys = append(l.keys, k)
+	if len(l.keys) < b.fill+1+b.tree.t-1 {
+		return nil
+	}
+
+	n := NewNode[V](b.tree.t, "")
+	n.Keys = append(n.Keys, l.keys[:b.fill]...)
+	sep := l.keys[b.fill]
+	l.keys = append(l.keys[:0], l.keys[b.fill+1:]...)
+
+	return b.push(0, n, &sep)
+}
+
+// push - internal function for writing Node n of level i and adding it to the next level followed by key sep
+// (sep is nil for the last Node of level). Node of the next level is written when there are enough children after it
+// for one more Node, so the last Node gets at least t children
+func (b *builder[V]) push(i int, n *Node[V], sep *V) error {
+	name, err := b.tree.storage.AllocName()
+	if err != nil {
+		return err
+	}
+	n.Name = name
+	if err = b.write(n); err != nil {
+		return err
+	}
+
+	if i+1 == len(b.levels) {
+		b.levels = append(b.levels, &buildLevel[V]{})
+	}
+	l := b.levels[i+1]
+	l.children = append(l.children, n.Name)
+	l.counts = append(l.counts, n.size())
+	if sep == nil {
+		return nil
+	}
+	l.keys = append(l.keys, *sep)
+
+	if len(l.children) < b.fill+1+b.tree.t {
+		return nil
+	}
+
+	p := b.internalNode(l, b.fill+1)
+	next := l.keys[0]
+	l.keys = l.keys[1:]
+
+	return b.push(i+1, p, &next)
+}
+
+// finish - internal function for putting remaining keys and children of every level to one or two nodes.
+// The only Node of the top level is root
+func (b *builder[V]) finish() error {
+	for i := 0; i < len(b.levels); i++ {
+		l := b.levels[i]
+
+		var nodes []*Node[V]
+		var sep V
+		if i == 0 {
+			nodes = append(nodes, NewNode[V](b.tree.t, ""))
+			keys := l.keys
+			if len(keys) > b.tree.maxKeysLength() {
+				m := (len(keys) - 1) / 2
+				nodes[0].Keys = append(nodes[0].Keys, keys[:m]...)
+				sep, keys = keys[m], keys[m+1:]
+				nodes = append(nodes, NewNode[V](b.tree.t, ""))
+			}
+			nodes[len(nodes)-1].Keys = append(nodes[len(nodes)-1].Keys, keys...)
+		} else {
+			if len(l.children) > 2*b.tree.t {
+				nodes = append(nodes, b.internalNode(l, len(l.children)/2))
+				sep, l.keys = l.keys[0], l.keys[1:]
+			}
+			nodes = append(nodes, b.internalNode(l, len(l.children)))
+		}
+
+		if i+1 == len(b.levels) && len(nodes) == 1 {
+			nodes[0].Name = RootName
+			return b.write(nodes[0])
+		}
+
+		if len(nodes) == 2 {
+			if err := b.push(i, nodes[0], &sep); err != nil {
+				return err
+			}
+		}
+		if err := b.push(i, nodes[len(nodes)-1], nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// internalNode - internal function for creating Node from the first c children of level l and keys between them.
+// Taken children and keys are removed from level
+func (b *builder[V]) internalNode(l *buildLevel[V], c int) *Node[V] {
+	n := NewNode[V](b.tree.t, "")
+	n.Leaf = false
+	n.Keys = append(n.Keys, l.keys[:c-1]...)
+	n.Children = append(n.Children, l.children[:c]...)
+	n.Counts = append(n.Counts, l.counts[:c]...)
+
+	l.keys = l.keys[c-1:]
+	l.children = l.children[c:]
+	l.counts = l.counts[c:]
+
+	return n
+}
+
+// write - internal function for writing built Node to batch
+func (b *builder[V]) write(n *Node[V]) error {
+	if err := b.tree.storage.Write(n); err != nil {
+		return err
+	}
+	b.tree.meta.NodeCount++
+
+	return nil
+}
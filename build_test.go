@@ -0,0 +1,111 @@
+package btree
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestBuildFromSorted(t1 *testing.T) {
+	for _, t := range []int{2, 3, 5} {
+		for _, fill := range []float64{0, 0.5, 0.8} {
+			for n := 0; n < 300; n += 7 {
+				ms, _ := NewMemoryStorage[int]("memory", t)
+				rs := &testStorage{MemoryStorage: ms}
+				tree, err := BuildFromSorted[int](t, rs, slices.Values(makeRange(0, n)), BuildOptions{FillFactor: fill})
+				if err != nil {
+					t1.Fatalf("BuildFromSorted(%v, %v, %v) error = %v", t, n, fill, err)
+				}
+
+				checkTreeBalance(tree, t1)
+				if got := collectKeys(tree.All()); !slices.Equal(got, makeRange(0, n)) {
+					t1.Fatalf("All() after BuildFromSorted(%v, %v, %v) got = %v", t, n, fill, got)
+				}
+				if len(ms.nodes) != tree.NodeCount() || ms.meta != tree.meta {
+					t1.Errorf("storage has %v nodes and meta %+v, tree has %+v", len(ms.nodes), ms.meta, tree.meta)
+				}
+				// all nodes and metadata are applied by one batch
+				if len(rs.batches) != 1 || len(rs.batches[0].Writes) != tree.NodeCount() || rs.batches[0].Meta == nil {
+					t1.Fatalf("BuildFromSorted(%v, %v, %v) applied %v batches", t, n, fill, len(rs.batches))
+				}
+			}
+		}
+	}
+}
+
+func TestBuildFromSorted_fill(t1 *testing.T) {
+	full, _ := NewMemoryStorage[int]("full", 3)
+	half, _ := NewMemoryStorage[int]("half", 3)
+	t, _ := BuildFromSorted[int](3, full, slices.Values(makeRange(0, 1000)), BuildOptions{})
+	h, _ := BuildFromSorted[int](3, half, slices.Values(makeRange(0, 1000)), BuildOptions{FillFactor: 0.5})
+	if t.NodeCount() >= h.NodeCount() {
+		t1.Errorf("NodeCount() with fill factors 1 and 0.5 got = %v, %v", t.NodeCount(), h.NodeCount())
+	}
+
+	// built tree can be changed
+	for i := 1000; i < 1100; i++ {
+		h.Insert(i)
+	}
+	for i := 0; i < 1000; i += 2 {
+		h.Delete(i)
+	}
+	checkTreeBalance(h, t1)
+	if h.Len() != 600 {
+		t1.Errorf("Len() got = %v, want 600", h.Len())
+	}
+}
+
+func TestBuildFromSorted_errors(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	_, err := BuildFromSorted[int](2, ms, slices.Values([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 3}), BuildOptions{})
+	if err == nil {
+		t1.Errorf("BuildFromSorted() of unsorted keys error = nil, want error")
+	}
+	// nothing is written
+	if len(ms.nodes) != 1 {
+		t1.Errorf("amount of nodes after failed building got = %v, want 1", len(ms.nodes))
+	}
+
+	// batch isn't applied: tree in storage stays empty
+	rs := &testStorage{MemoryStorage: ms, applyErr: errors.New("apply error")}
+	if _, err = BuildFromSorted[int](2, rs, slices.Values(makeRange(0, 100)), BuildOptions{}); err == nil {
+		t1.Errorf("BuildFromSorted() with failed Apply error = nil, want error")
+	}
+	if t, _ := NewTree[int](2, ms); len(ms.nodes) != 1 || t.Len() != 0 {
+		t1.Errorf("after failed building storage has %v nodes and tree %v keys", len(ms.nodes), t.Len())
+	}
+
+	if _, err = BuildFromSorted[int](2, ms, slices.Values([]int{1, 1}), BuildOptions{}); err == nil {
+		t1.Errorf("BuildFromSorted() of duplicated keys error = nil, want error")
+	}
+	if _, err = BuildFromSorted[int](2, ms, slices.Values([]int{1}), BuildOptions{FillFactor: 1.5}); err == nil {
+		t1.Errorf("BuildFromSorted() with fill factor 1.5 error = nil, want error")
+	}
+
+	t, _ := NewTree[int](2, ms)
+	t.Insert(1)
+	if _, err = BuildFromSorted[int](2, ms, slices.Values([]int{2}), BuildOptions{}); err == nil {
+		t1.Errorf("BuildFromSorted() to not empty storage error = nil, want error")
+	}
+}
+
+func TestBuildFromSorted_DiskStorage(t1 *testing.T) {
+	folder := "build_from_sorted"
+	defer os.RemoveAll(folder)
+
+	ds, _ := NewDiskStorage[int](folder, 2)
+	if _, err := BuildFromSorted[int](2, ds, slices.Values(makeRange(0, 100)), BuildOptions{}); err != nil {
+		t1.Fatalf("BuildFromSorted() error = %v", err)
+	}
+
+	t, err := Open[int](folder, 2)
+	if err != nil {
+		t1.Fatalf("Open() error = %v", err)
+	}
+	checkTreeBalance(t, t1)
+	if got := collectKeys(t.All()); !reflect.DeepEqual(got, makeRange(0, 100)) {
+		t1.Errorf("All() got = %v", got)
+	}
+}
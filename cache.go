@@ -0,0 +1,434 @@
+package btree
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// DefaultCacheSize - is an amount of nodes which CachedStorage keeps if other size isn't set
+const DefaultCacheSize = 1024
+
+// CacheMode is a mode of writing changes of CachedStorage to wrapped storage
+type CacheMode int
+
+const (
+	// WriteThrough - every change is written to wrapped storage at once
+	WriteThrough CacheMode = iota
+	// WriteBack - changes are kept in memory until Flush (or until changed Node should be evicted)
+	WriteBack
+)
+
+// CacheOptions is the structure of CachedStorage's parameters.
+// Size is a max amount of nodes in cache (DefaultCacheSize if it's zero).
+// Mode is a mode of writing changes to wrapped storage (WriteThrough by default)
+type CacheOptions struct {
+	Size int
+	Mode CacheMode
+}
+
+// CacheStats is the structure of CachedStorage's counters.
+// Hits is an amount of nodes which were read from cache, Misses is an amount of nodes which were read from wrapped storage
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// CachedStorage - is a storage which keeps recently used decoded nodes of wrapped storage in memory.
+// When cache is full, the least recently used Node is evicted.
+// In WriteBack mode changes (nodes, deleting, metadata and references) are kept in memory and are written to wrapped
+// storage together by Flush. If changed Node should be evicted, all changes are flushed, so wrapped storage always
+// has all changes of applied batches or none of them
+type CachedStorage[V any] struct {
+	inner NodeStorage[V]
+	size  int
+	mode  CacheMode
+
+	mu      sync.Mutex
+	lru     *list.List               // cached nodes from the most recently used to the least one
+	entries map[string]*list.Element // cached nodes by names
+	stats   CacheStats
+
+	// changes which aren't written to wrapped storage in WriteBack mode
+	ops     []Op[V]
+	deletes map[string]bool
+	meta    *Meta
+	refs    map[string]int
+}
+
+// cacheEntry - internal structure of cached Node. Dirty Node isn't written to wrapped storage yet
+type cacheEntry[V any] struct {
+	node  *Node[V]
+	dirty bool
+}
+
+// NewCachedStorage - function for creating of CachedStorage
+// - param inner is a wrapped storage
+// - param opts is a size of cache and mode of writing changes
+func NewCachedStorage[V any](inner NodeStorage[V], opts CacheOptions) (*CachedStorage[V], error) {
+	if opts.Size == 0 {
+		opts.Size = DefaultCacheSize
+	}
+	if opts.Size < 0 {
+		return nil, errors.New("size of cache can't be negative")
+	}
+	if opts.Mode != WriteThrough && opts.Mode != WriteBack {
+		return nil, fmt.Errorf("unknown cache mode %d", opts.Mode)
+	}
+
+	return &CachedStorage[V]{
+		inner:   inner,
+		size:    opts.Size,
+		mode:    opts.Mode,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		deletes: make(map[string]bool),
+		refs:    make(map[string]int),
+	}, nil
+}
+
+// Name - this function returns name of wrapped storage
+func (cs *CachedStorage[V]) Name() string {
+	return cs.inner.Name()
+}
+
+// AllocName - function for allocating name for new Node in wrapped storage
+func (cs *CachedStorage[V]) AllocName() (string, error) {
+	return cs.inner.AllocName()
+}
+
+// reserveName - internal function for allocating name again in wrapped storage when log is replayed
+func (cs *CachedStorage[V]) reserveName(name string) error {
+	if nr, ok := cs.inner.(nameReserver); ok {
+		return nr.reserveName(name)
+	}
+
+	return nil
+}
+
+// Read - function for reading Node from cache or from wrapped storage
+func (cs *CachedStorage[V]) Read(name string) (*Node[V], error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if e, ok := cs.entries[name]; ok {
+		cs.stats.Hits++
+		cs.lru.MoveToFront(e)
+		return e.Value.(*cacheEntry[V]).node.clone(), nil
+	}
+	if cs.deletes[name] {
+		return nil, fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+
+	cs.stats.Misses++
+	n, err := cs.inner.Read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.put(n.clone(), false)
+
+	return n, cs.evict()
+}
+
+// Write - function for writing Node to cache and to wrapped storage (in WriteBack mode - only to cache)
+func (cs *CachedStorage[V]) Write(n *Node[V]) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.mode == WriteBack {
+		delete(cs.deletes, n.Name)
+		cs.put(n.clone(), true)
+		return cs.evict()
+	}
+
+	if err := cs.inner.Write(n); err != nil {
+		cs.remove(n.Name)
+		return err
+	}
+	cs.put(n.clone(), false)
+
+	return cs.evict()
+}
+
+// Delete - function for deleting Node from cache and from wrapped storage (in WriteBack mode - on Flush)
+func (cs *CachedStorage[V]) Delete(name string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.remove(name)
+	if cs.mode == WriteThrough {
+		return cs.inner.Delete(name)
+	}
+
+	if cs.deletes[name] {
+		return fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+	cs.deletes[name] = true
+	delete(cs.refs, name)
+
+	return nil
+}
+
+// Apply - function for applying Batch to wrapped storage and cache (in WriteBack mode - only to cache).
+// Batch is applied atomically if wrapped storage implements BatchStorage
+func (cs *CachedStorage[V]) Apply(b Batch[V]) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.mode == WriteThrough {
+		err := applyBatch(cs.inner, b)
+		for _, n := range b.Writes {
+			cs.remove(n.Name)
+		}
+		for _, name := range b.Deletes {
+			cs.remove(name)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, n := range b.Writes {
+			cs.put(n.clone(), false)
+		}
+
+		return cs.evict()
+	}
+
+	cs.ops = append(cs.ops, b.Ops...)
+	for name, c := range b.Refs {
+		cs.refs[name] = c
+	}
+	for _, name := range b.Deletes {
+		cs.remove(name)
+		cs.deletes[name] = true
+		delete(cs.refs, name)
+	}
+	if b.Meta != nil {
+		m := *b.Meta
+		cs.meta = &m
+	}
+	for _, n := range b.Writes {
+		delete(cs.deletes, n.Name)
+		cs.put(n.clone(), true)
+	}
+
+	// nodes are evicted after the whole Batch is kept, so flush doesn't write a part of it
+	return cs.evict()
+}
+
+// ReadMeta - function for reading Tree's metadata from cache or from wrapped storage
+func (cs *CachedStorage[V]) ReadMeta() (Meta, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.meta != nil {
+		return *cs.meta, nil
+	}
+	if ms, ok := cs.inner.(MetaStorage[V]); ok {
+		return ms.ReadMeta()
+	}
+
+	return Meta{}, fs.ErrNotExist
+}
+
+// WriteMeta - function for writing Tree's metadata to wrapped storage (in WriteBack mode - on Flush)
+func (cs *CachedStorage[V]) WriteMeta(m Meta) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.mode == WriteBack {
+		cs.meta = &m
+		return nil
+	}
+
+	if ms, ok := cs.inner.(MetaStorage[V]); ok {
+		return ms.WriteMeta(m)
+	}
+
+	return nil
+}
+
+// ReadRefs - function for reading amounts of references to shared nodes from wrapped storage
+// together with amounts which aren't flushed yet
+func (cs *CachedStorage[V]) ReadRefs() (map[string]int, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	rs, ok := cs.inner.(RefStorage[V])
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+
+	refs, err := rs.ReadRefs()
+	if err != nil {
+		return nil, err
+	}
+	refs = maps.Clone(refs)
+	if refs == nil {
+		refs = make(map[string]int)
+	}
+	for name, c := range cs.refs {
+		if c == 1 {
+			delete(refs, name)
+		} else {
+			refs[name] = c
+		}
+	}
+	for name := range cs.deletes {
+		delete(refs, name)
+	}
+
+	return refs, nil
+}
+
+// Manifest - function for reading parameters of Tree from wrapped storage
+func (cs *CachedStorage[V]) Manifest() (Manifest, error) {
+	if ms, ok := cs.inner.(ManifestStorage[V]); ok {
+		return ms.Manifest()
+	}
+
+	return Manifest{}, errors.ErrUnsupported
+}
+
+// nodeNames - internal function: returns names of all nodes saved in wrapped storage together with nodes
+// which aren't flushed yet
+func (cs *CachedStorage[V]) nodeNames() ([]string, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	nl, ok := cs.inner.(nodeLister)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	names, err := nl.nodeNames()
+	if err != nil {
+		return nil, err
+	}
+
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return cs.deletes[name] || cs.isDirty(name)
+	})
+	for e := cs.lru.Front(); e != nil; e = e.Next() {
+		if ce := e.Value.(*cacheEntry[V]); ce.dirty {
+			names = append(names, ce.node.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// Flush - function for writing all changes which are kept in memory to wrapped storage together.
+// In WriteThrough mode there are no such changes
+func (cs *CachedStorage[V]) Flush() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.flush()
+}
+
+// Sync - function for flushing changes and syncing wrapped storage (if it can be synced)
+func (cs *CachedStorage[V]) Sync() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if err := cs.flush(); err != nil {
+		return err
+	}
+
+	if s, ok := cs.inner.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+
+	return nil
+}
+
+// Stats - function which returns counters of cache hits and misses
+func (cs *CachedStorage[V]) Stats() CacheStats {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.stats
+}
+
+// flush - internal function for writing changes of WriteBack mode to wrapped storage as one Batch
+func (cs *CachedStorage[V]) flush() error {
+	b := Batch[V]{Ops: cs.ops, Meta: cs.meta}
+	for e := cs.lru.Back(); e != nil; e = e.Prev() {
+		if ce := e.Value.(*cacheEntry[V]); ce.dirty {
+			b.Writes = append(b.Writes, ce.node)
+		}
+	}
+	for name := range cs.deletes {
+		b.Deletes = append(b.Deletes, name)
+	}
+	if len(cs.refs) > 0 {
+		b.Refs = cs.refs
+	}
+	if len(b.Writes) == 0 && len(b.Deletes) == 0 && b.Meta == nil && b.Refs == nil {
+		return nil
+	}
+
+	if err := applyBatch(cs.inner, b); err != nil {
+		return err
+	}
+
+	for e := cs.lru.Front(); e != nil; e = e.Next() {
+		e.Value.(*cacheEntry[V]).dirty = false
+	}
+	cs.ops = nil
+	cs.meta = nil
+	clear(cs.deletes)
+	clear(cs.refs)
+
+	return nil
+}
+
+// put - internal function for keeping Node in cache as the most recently used one
+func (cs *CachedStorage[V]) put(n *Node[V], dirty bool) {
+	if e, ok := cs.entries[n.Name]; ok {
+		ce := e.Value.(*cacheEntry[V])
+		ce.node = n
+		ce.dirty = ce.dirty || dirty
+		cs.lru.MoveToFront(e)
+		return
+	}
+
+	cs.entries[n.Name] = cs.lru.PushFront(&cacheEntry[V]{node: n, dirty: dirty})
+}
+
+// evict - internal function for evicting the least recently used nodes while cache is full.
+// If evicted Node is dirty, all changes are flushed before it
+func (cs *CachedStorage[V]) evict() error {
+	for cs.lru.Len() > cs.size {
+		e := cs.lru.Back()
+		if e.Value.(*cacheEntry[V]).dirty {
+			if err := cs.flush(); err != nil {
+				return err
+			}
+		}
+		cs.lru.Remove(e)
+		delete(cs.entries, e.Value.(*cacheEntry[V]).node.Name)
+	}
+
+	return nil
+}
+
+// isDirty - internal function: returns true if Node with name is changed in cache, but isn't flushed yet
+func (cs *CachedStorage[V]) isDirty(name string) bool {
+	e, ok := cs.entries[name]
+
+	return ok && e.Value.(*cacheEntry[V]).dirty
+}
+
+// remove - internal function for removing Node from cache
+func (cs *CachedStorage[V]) remove(name string) {
+	if e, ok := cs.entries[name]; ok {
+		cs.lru.Remove(e)
+		delete(cs.entries, name)
+	}
+}
@@ -0,0 +1,167 @@
+package btree
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCachedStorage_stats(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	cs, err := NewCachedStorage[int](ms, CacheOptions{})
+	if err != nil {
+		t1.Fatalf("NewCachedStorage() error = %v", err)
+	}
+	t, _ := NewTree[int](2, cs)
+	for i := 0; i < 100; i++ {
+		t.Insert(i)
+	}
+	checkTreeBalance(t, t1)
+
+	// all nodes are kept in cache, so searching doesn't read wrapped storage
+	before := cs.Stats()
+	if ok, _ := t.Exists(50); !ok {
+		t1.Errorf("Exists(50) got = false, want true")
+	}
+	after := cs.Stats()
+	if after.Misses != before.Misses || after.Hits <= before.Hits {
+		t1.Errorf("Stats() got = %+v after %+v, want only hits", after, before)
+	}
+
+	// changes are written to wrapped storage at once
+	if meta, _ := ms.ReadMeta(); meta != (Meta{Len: 100, Height: t.Height(), NodeCount: t.NodeCount()}) {
+		t1.Errorf("ReadMeta() of wrapped storage got = %+v", meta)
+	}
+}
+
+func TestCachedStorage_WriteBack(t1 *testing.T) {
+	folder := "cached_storage_write_back"
+	defer os.RemoveAll(folder)
+
+	ds, _ := NewDiskStorage[int](folder, 2)
+	cs, _ := NewCachedStorage[int](ds, CacheOptions{Mode: WriteBack})
+	t, _ := NewTree[int](2, cs)
+	for i := 0; i < 100; i++ {
+		t.Insert(i)
+	}
+	for i := 0; i < 100; i += 3 {
+		t.Delete(i)
+	}
+
+	// changes aren't written until Flush
+	saved, _ := Open[int](folder, 2)
+	if saved.Len() != 0 {
+		t1.Errorf("Len() of saved tree before Flush() got = %v, want 0", saved.Len())
+	}
+
+	if err := cs.Flush(); err != nil {
+		t1.Fatalf("Flush() error = %v", err)
+	}
+	saved, _ = Open[int](folder, 2)
+	checkTreeBalance(saved, t1)
+	if got, want := collectKeys(saved.All()), collectKeys(t.All()); !reflect.DeepEqual(got, want) {
+		t1.Errorf("All() of saved tree got = %v, want %v", got, want)
+	}
+	files, _ := os.ReadDir(folder)
+	if len(files) != t.NodeCount()+2 {
+		t1.Errorf("amount of files got = %v, want %v", len(files), t.NodeCount()+2)
+	}
+}
+
+func TestCachedStorage_evict(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	cs, _ := NewCachedStorage[int](ms, CacheOptions{Size: 4, Mode: WriteBack})
+	t, _ := NewTree[int](2, cs)
+	for i := 0; i < 100; i++ {
+		t.Insert(i)
+	}
+	if cs.Stats().Misses == 0 {
+		t1.Errorf("Stats() got = %+v, want misses", cs.Stats())
+	}
+
+	// evicted changed node flushes all changes: wrapped storage has a whole tree
+	meta, _ := ms.ReadMeta()
+	if meta.Len == 0 || len(ms.nodes) != meta.NodeCount {
+		t1.Errorf("wrapped storage has %v nodes, meta %+v", len(ms.nodes), meta)
+	}
+
+	cs.Flush()
+	saved, _ := NewTree[int](2, ms)
+	checkTreeBalance(saved, t1)
+	if saved.Len() != 100 {
+		t1.Errorf("Len() of saved tree got = %v, want 100", saved.Len())
+	}
+}
+
+func TestCachedStorage_Clone(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	cs, _ := NewCachedStorage[int](ms, CacheOptions{Mode: WriteBack})
+	t, _ := NewTree[int](2, cs)
+	for i := 0; i < 50; i++ {
+		t.Insert(i)
+	}
+	c, err := t.Clone()
+	if err != nil {
+		t1.Fatalf("Clone() error = %v", err)
+	}
+	c.Insert(100)
+	cs.Flush()
+	if refs, _ := ms.ReadRefs(); len(refs) == 0 {
+		t1.Errorf("ReadRefs() of wrapped storage got = %v, want shared nodes", refs)
+	}
+
+	cs, _ = NewCachedStorage[int](plainStorage{ms}, CacheOptions{})
+	t, _ = NewTree[int](2, cs)
+	if _, err = t.Clone(); !errors.Is(err, errors.ErrUnsupported) {
+		t1.Errorf("Clone() error = %v, want errors.ErrUnsupported", err)
+	}
+}
+
+func TestCachedStorage_concurrent(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	cs, _ := NewCachedStorage[int](ms, CacheOptions{Size: 16, Mode: WriteBack})
+	t, _ := NewTree[int](2, cs)
+
+	const writers, keys = 4, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for k := w * keys; k < (w+1)*keys; k++ {
+				if err := t.Insert(k); err != nil {
+					t1.Errorf("Insert(%d) error = %v", k, err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				if _, err := t.Exists(i); err != nil {
+					t1.Errorf("Exists(%d) error = %v", i, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := cs.Flush(); err != nil {
+		t1.Fatalf("Flush() error = %v", err)
+	}
+	if meta, _ := ms.ReadMeta(); meta.Len != writers*keys {
+		t1.Errorf("ReadMeta() of wrapped storage got = %+v, want Len %v", meta, writers*keys)
+	}
+	checkTreeBalance(t, t1)
+}
+
+func TestNewCachedStorage_errors(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	if _, err := NewCachedStorage[int](ms, CacheOptions{Size: -1}); err == nil {
+		t1.Errorf("NewCachedStorage() with negative size error = nil, want error")
+	}
+	if _, err := NewCachedStorage[int](ms, CacheOptions{Mode: 5}); err == nil {
+		t1.Errorf("NewCachedStorage() with unknown mode error = nil, want error")
+	}
+}
@@ -0,0 +1,349 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// refTable - internal structure of references to nodes which are shared by Tree, its clones and snapshots.
+// It's shared by all trees which work with the same storage. Node without amount is referenced once
+type refTable struct {
+	mu     sync.Mutex
+	saved  bool           // amounts of references are saved in storage, so Tree can be cloned
+	counts map[string]int // references from nodes and from clones' roots, they are saved in RefStorage
+	pins   map[string]int // references from roots of snapshots, they are kept only in memory
+}
+
+// newRefTable - internal function for creating table of references which are saved in storage s
+func newRefTable[V any](s NodeStorage[V]) (*refTable, error) {
+	rt := &refTable{
+		counts: make(map[string]int),
+		pins:   make(map[string]int),
+	}
+
+	if rs, ok := s.(RefStorage[V]); ok {
+		counts, err := rs.ReadRefs()
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			// storage wraps other storage which doesn't keep references
+		case err != nil:
+			return nil, err
+		default:
+			rt.saved = true
+			for name, c := range counts {
+				rt.counts[name] = c
+			}
+		}
+	}
+
+	return rt, nil
+}
+
+// shared - returns true if some nodes are referenced not once, so changes of Tree should be copied
+func (rt *refTable) shared() bool {
+	return len(rt.counts) > 0 || len(rt.pins) > 0
+}
+
+// count - returns amount of references to Node from nodes and clones' roots.
+// Amounts which were changed, but weren't applied yet are taken from changed
+func (rt *refTable) count(changed map[string]int, name string) int {
+	if c, ok := changed[name]; ok {
+		return c
+	}
+	if c, ok := rt.counts[name]; ok {
+		return c
+	}
+
+	return 1
+}
+
+// update - applies new amounts of references refs and removes amounts of deleted nodes
+func (rt *refTable) update(refs map[string]int, deletes []string) {
+	for name, c := range refs {
+		if c == 1 {
+			delete(rt.counts, name)
+		} else {
+			rt.counts[name] = c
+		}
+	}
+	for _, name := range deletes {
+		delete(rt.counts, name)
+	}
+}
+
+// Clone is a function for creating a copy of Tree which can be changed independently.
+// Clone doesn't copy nodes: the only new Node is a root, other nodes are shared until one of trees changes them.
+// Changed Tree copies nodes on the path from its root to changed Node, other nodes stay shared.
+// Storage should implement RefStorage, else errors.ErrUnsupported is returned
+func (t *Tree[V]) Clone() (*Tree[V], error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if !t.refs.saved {
+		return nil, fmt.Errorf("storage %s doesn't keep references of nodes: %w", t.storage.Name(), errors.ErrUnsupported)
+	}
+
+	root, err := t.storage.Read(t.root)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name, err = t.storage.AllocName(); err != nil {
+		return nil, err
+	}
+
+	t.refs.mu.Lock()
+	defer t.refs.mu.Unlock()
+
+	b := Batch[V]{Writes: []*Node[V]{root}, Refs: make(map[string]int, len(root.Children))}
+	for _, name := range root.Children {
+		b.Refs[name] = t.refs.count(b.Refs, name) + 1
+	}
+	if err = applyBatch(t.storage, b); err != nil {
+		return nil, err
+	}
+	t.refs.update(b.Refs, nil)
+
+	return &Tree[V]{
+		storage: t.storage,
+		t:       t.t,
+		compare: t.compare,
+		meta:    t.meta,
+		root:    root.Name,
+		refs:    t.refs,
+	}, nil
+}
+
+// OpenClone is a function for opening a clone of Tree which was created earlier in the same storage
+// - param root is a name of clone's root (see Root)
+func (t *Tree[V]) OpenClone(root string) (*Tree[V], error) {
+	c := &Tree[V]{
+		storage: t.storage,
+		t:       t.t,
+		compare: t.compare,
+		root:    root,
+		refs:    t.refs,
+	}
+	if err := c.loadMeta(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Root is a function which returns name of Tree's root: RootName or name of clone's root
+func (t *Tree[V]) Root() string {
+	return t.root
+}
+
+// Drop is a function for deleting clone of Tree: its nodes which aren't shared with other trees are deleted from storage.
+// Tree with root RootName can't be dropped. Tree can't be used after Drop
+func (t *Tree[V]) Drop() error {
+	if t.root == RootName {
+		return errors.New("only clone of tree can be dropped")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.refs.mu.Lock()
+	defer t.refs.mu.Unlock()
+
+	changed := map[string]int{t.root: 0}
+	deletes, err := freeNodes(t.storage, t.refs, changed, []string{t.root})
+	if err != nil {
+		return err
+	}
+
+	return t.applyRefs(Batch[V]{Deletes: deletes, Refs: changed})
+}
+
+// applyRefs - internal function for applying Batch which changes only references and deletes nodes
+func (t *Tree[V]) applyRefs(b Batch[V]) error {
+	for _, name := range b.Deletes {
+		delete(b.Refs, name)
+	}
+	if len(b.Deletes) == 0 && len(b.Refs) == 0 {
+		return nil
+	}
+
+	if err := applyBatch(t.storage, b); err != nil {
+		return err
+	}
+	t.refs.update(b.Refs, b.Deletes)
+
+	return nil
+}
+
+// freeNodes - internal function: returns names of nodes which aren't referenced anymore (param free)
+// and nodes which were referenced only by them. Amounts of references from deleted nodes are removed from changed
+func freeNodes[V any](s NodeStorage[V], rt *refTable, changed map[string]int, free []string) ([]string, error) {
+	var deletes []string
+	for len(free) > 0 {
+		name := free[len(free)-1]
+		free = free[:len(free)-1]
+		deletes = append(deletes, name)
+
+		n, err := s.Read(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range n.Children {
+			changed[c] = rt.count(changed, c) - 1
+			if changed[c] == 0 && rt.pins[c] == 0 {
+				free = append(free, c)
+			}
+		}
+	}
+
+	return deletes, nil
+}
+
+// copyOnWrite - internal function: changes batch of Tree t, so nodes which are shared with clones or snapshots
+// aren't changed. Shared nodes are written with new names and their parents refer to new names.
+// Every changed Node can be shared through its ancestors, so ancestors are added to batch and nodes are checked
+// from root to leaves: copy of Node adds references to all its children. Nodes which aren't referenced
+// anymore are deleted. New amounts of references are saved to Batch.Refs
+func (t *Tree[V]) copyOnWrite(b *batchStorage[V], batch Batch[V]) (Batch[V], error) {
+	// nodes allocated by batch aren't referenced yet
+	allocated := make(map[string]bool, len(b.allocated))
+	changed := make(map[string]int)
+	for _, name := range b.allocated {
+		allocated[name] = true
+		changed[name] = 0
+	}
+	add := func(name string, d int) {
+		changed[name] = t.refs.count(changed, name) + d
+	}
+
+	written := make(map[string]*Node[V], len(batch.Writes))
+	for _, n := range batch.Writes {
+		written[n.Name] = n
+	}
+	for _, n := range batch.Writes {
+		if allocated[n.Name] || n.Name == t.root {
+			continue
+		}
+		path, err := t.pathTo(b, n)
+		if err != nil {
+			return batch, err
+		}
+		for _, p := range path {
+			if _, ok := written[p.Name]; !ok {
+				written[p.Name] = p
+			}
+		}
+	}
+
+	renamed := make(map[string]string)
+	writes := make([]*Node[V], 0, len(written))
+	for queue := []string{t.root}; len(queue) > 0; queue = queue[1:] {
+		n, ok := written[queue[0]]
+		if !ok {
+			continue
+		}
+		writes = append(writes, n)
+
+		switch {
+		case allocated[n.Name]:
+			for _, c := range n.Children {
+				add(c, 1)
+			}
+		case n.Name != t.root && t.refs.count(changed, n.Name)+t.refs.pins[n.Name] > 1:
+			// parent refers to the copy instead of shared Node, old Node keeps its children
+			name, err := t.storage.AllocName()
+			if err != nil {
+				return batch, err
+			}
+			renamed[n.Name] = name
+			add(n.Name, -1)
+			changed[name] = 1
+			for _, c := range n.Children {
+				add(c, 1)
+			}
+		default:
+			old, err := b.base.Read(n.Name)
+			if err != nil {
+				return batch, err
+			}
+			for _, c := range old.Children {
+				add(c, -1)
+			}
+			for _, c := range n.Children {
+				add(c, 1)
+			}
+		}
+
+		for _, c := range n.Children {
+			if _, ok := written[c]; ok {
+				queue = append(queue, c)
+			}
+		}
+	}
+	if len(writes) != len(written) {
+		return batch, errors.New("changed Node isn't found in tree")
+	}
+
+	var deletes, free []string
+	for _, name := range batch.Deletes {
+		if allocated[name] {
+			deletes = append(deletes, name)
+		}
+	}
+	for name, c := range changed {
+		if c == 0 && t.refs.pins[name] == 0 && !allocated[name] {
+			free = append(free, name)
+		}
+	}
+	freed, err := freeNodes(b.base, t.refs, changed, free)
+	if err != nil {
+		return batch, err
+	}
+	deletes = append(deletes, freed...)
+
+	for i, n := range writes {
+		c := n.clone()
+		if name, ok := renamed[c.Name]; ok {
+			c.Name = name
+		}
+		for j, child := range c.Children {
+			if name, ok := renamed[child]; ok {
+				c.Children[j] = name
+			}
+		}
+		writes[i] = c
+	}
+	for _, name := range deletes {
+		delete(changed, name)
+	}
+
+	batch.Writes, batch.Deletes, batch.Refs = writes, deletes, changed
+
+	return batch, nil
+}
+
+// pathTo - internal function: returns ancestors of Node n in Tree which nodes are read from storage s
+func (t *Tree[V]) pathTo(s NodeStorage[V], n *Node[V]) ([]*Node[V], error) {
+	k := n.Keys[0]
+	name := t.root
+	var path []*Node[V]
+	for {
+		p, err := s.Read(name)
+		if err != nil {
+			return nil, err
+		}
+		if p.Leaf {
+			return nil, fmt.Errorf("Node %s isn't found in tree", n.Name)
+		}
+		path = append(path, p)
+
+		i := 0
+		for i < len(p.Keys) && t.compare(k, p.Keys[i]) > 0 {
+			i++
+		}
+		if p.Children[i] == n.Name {
+			return path, nil
+		}
+		name = p.Children[i]
+	}
+}
@@ -0,0 +1,225 @@
+package btree
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestTree_Clone(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	for i := 0; i < 100; i++ {
+		t.Insert(i)
+	}
+	nodes := len(ms.nodes)
+
+	c, err := t.Clone()
+	if err != nil {
+		t1.Fatalf("Clone() error = %v", err)
+	}
+	// clone writes only its root
+	if len(ms.nodes) != nodes+1 {
+		t1.Errorf("amount of nodes in storage after Clone() got = %v, want %v", len(ms.nodes), nodes+1)
+	}
+
+	// changed Node is copied with its ancestors, other nodes stay shared
+	c.Insert(-1)
+	if got, want := len(ms.nodes), nodes+c.Height(); got != want {
+		t1.Errorf("amount of nodes in storage after Insert() got = %v, want %v", got, want)
+	}
+
+	for i := 0; i < 100; i += 2 {
+		t.Delete(i)
+	}
+	for i := 100; i < 150; i++ {
+		c.Insert(i)
+	}
+	checkTreeBalance(t, t1)
+	checkTreeBalance(c, t1)
+
+	want := make([]int, 0, 50)
+	for i := 1; i < 100; i += 2 {
+		want = append(want, i)
+	}
+	if got := collectKeys(t.All()); !reflect.DeepEqual(got, want) {
+		t1.Errorf("All() of tree got = %v, want %v", got, want)
+	}
+	if got := collectKeys(c.All()); !reflect.DeepEqual(got, makeRange(-1, 150)) {
+		t1.Errorf("All() of clone got = %v", got)
+	}
+
+	// dropped clone leaves only nodes of tree
+	if err = c.Drop(); err != nil {
+		t1.Fatalf("Drop() error = %v", err)
+	}
+	if len(ms.nodes) != t.NodeCount() || len(ms.refs) != 0 || len(t.refs.counts) != 0 {
+		t1.Errorf("after Drop() storage has %v nodes and %v refs, want %v nodes", len(ms.nodes), len(ms.refs), t.NodeCount())
+	}
+	if err = t.Drop(); err == nil {
+		t1.Errorf("Drop() of tree error = nil, want error")
+	}
+
+	// without clones nodes are changed in place
+	t.Insert(1000)
+	if len(ms.nodes) != t.NodeCount() {
+		t1.Errorf("amount of nodes in storage got = %v, want %v", len(ms.nodes), t.NodeCount())
+	}
+}
+
+func TestTree_Clone_random(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	trees := []*Tree[int]{t}
+	models := []map[int]bool{{}}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 3000; i++ {
+		j := r.Intn(len(trees))
+		k := r.Intn(200)
+		switch {
+		case r.Intn(100) == 0 && len(trees) < 6:
+			c, err := trees[j].Clone()
+			if err != nil {
+				t1.Fatalf("Clone() error = %v", err)
+			}
+			trees = append(trees, c)
+			models = append(models, copySet(models[j]))
+		case r.Intn(200) == 0 && j > 0:
+			if err := trees[j].Drop(); err != nil {
+				t1.Fatalf("Drop() error = %v", err)
+			}
+			trees = slices.Delete(trees, j, j+1)
+			models = slices.Delete(models, j, j+1)
+		case models[j][k]:
+			if err := trees[j].Delete(k); err != nil {
+				t1.Fatalf("Delete(%v) error = %v", k, err)
+			}
+			delete(models[j], k)
+		default:
+			if err := trees[j].Insert(k); err != nil {
+				t1.Fatalf("Insert(%v) error = %v", k, err)
+			}
+			models[j][k] = true
+		}
+	}
+
+	for j, tree := range trees {
+		checkTreeBalance(tree, t1)
+		want := make([]int, 0, len(models[j]))
+		for k := range models[j] {
+			want = append(want, k)
+		}
+		slices.Sort(want)
+		if got := collectKeys(tree.All()); !slices.Equal(got, want) {
+			t1.Errorf("All() of tree %v got = %v, want %v", j, got, want)
+		}
+	}
+
+	for _, tree := range trees[1:] {
+		tree.Drop()
+	}
+	if len(ms.nodes) != t.NodeCount() || len(ms.refs) != 0 {
+		t1.Errorf("after Drop() storage has %v nodes and %v refs, want %v nodes", len(ms.nodes), len(ms.refs), t.NodeCount())
+	}
+}
+
+func TestTree_Clone_snapshot(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	for i := 0; i < 50; i++ {
+		t.Insert(i)
+	}
+
+	s, _ := t.Snapshot()
+	c, _ := t.Clone()
+	for i := 0; i < 50; i++ {
+		t.Delete(i)
+		c.Insert(i + 50)
+	}
+	checkTreeBalance(c, t1)
+
+	if got := collectKeys(s.All()); !reflect.DeepEqual(got, makeRange(0, 50)) {
+		t1.Errorf("All() of snapshot got = %v", got)
+	}
+	if got := collectKeys(c.All()); !reflect.DeepEqual(got, makeRange(0, 100)) {
+		t1.Errorf("All() of clone got = %v", got)
+	}
+
+	s.Release()
+	c.Drop()
+	if len(ms.nodes) != t.NodeCount() || len(ms.refs) != 0 {
+		t1.Errorf("storage has %v nodes and %v refs, want %v nodes", len(ms.nodes), len(ms.refs), t.NodeCount())
+	}
+}
+
+func TestTree_Clone_DiskStorage(t1 *testing.T) {
+	folder := "clone_disk_storage"
+	defer os.RemoveAll(folder)
+
+	s, _ := NewDiskStorage[int](folder, 2)
+	t, _ := NewTree[int](2, s)
+	for i := 0; i < 30; i++ {
+		t.Insert(i)
+	}
+	c, err := t.Clone()
+	if err != nil {
+		t1.Fatalf("Clone() error = %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		c.Delete(i)
+		t.Insert(i + 100)
+	}
+	root := c.Root()
+
+	// references and clone are kept in storage
+	t, err = Open[int](folder, 2)
+	if err != nil {
+		t1.Fatalf("Open() error = %v", err)
+	}
+	if c, err = t.OpenClone(root); err != nil {
+		t1.Fatalf("OpenClone() error = %v", err)
+	}
+	checkTreeBalance(t, t1)
+	checkTreeBalance(c, t1)
+	if got := collectKeys(c.All()); !reflect.DeepEqual(got, makeRange(10, 30)) {
+		t1.Errorf("All() of clone got = %v", got)
+	}
+	if t.Len() != 40 {
+		t1.Errorf("Len() of tree got = %v, want 40", t.Len())
+	}
+
+	c.Insert(1000)
+	if err = c.Drop(); err != nil {
+		t1.Fatalf("Drop() error = %v", err)
+	}
+	files, _ := os.ReadDir(folder)
+	// nodes, meta, manifest and refs
+	if len(files) != t.NodeCount()+3 {
+		t1.Errorf("amount of files got = %v, want %v", len(files), t.NodeCount()+3)
+	}
+	if refs, _ := s.ReadRefs(); len(refs) != 0 {
+		t1.Errorf("ReadRefs() got = %v, want empty", refs)
+	}
+}
+
+func TestTree_Clone_unsupported(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, plainStorage{ms})
+	if _, err := t.Clone(); !errors.Is(err, errors.ErrUnsupported) {
+		t1.Errorf("Clone() error = %v, want errors.ErrUnsupported", err)
+	}
+}
+
+// copySet - returns copy of set
+func copySet(m map[int]bool) map[int]bool {
+	c := make(map[int]bool, len(m))
+	for k := range m {
+		c[k] = true
+	}
+
+	return c
+}
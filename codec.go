@@ -0,0 +1,313 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Codec is an interface of Node's encoding which is used by storages.
+// Name of codec is saved by storage, so the same codec is used for reading saved nodes
+type Codec[V any] interface {
+	Name() string
+	Marshal(n *Node[V]) ([]byte, error)
+	Unmarshal(data []byte) (*Node[V], error)
+}
+
+// JSONCodec - is a Codec which encodes nodes to json
+type JSONCodec[V any] struct{}
+
+// Name - returns name of JSONCodec
+func (JSONCodec[V]) Name() string {
+	return "json"
+}
+
+// Marshal - encodes Node to json
+func (JSONCodec[V]) Marshal(n *Node[V]) ([]byte, error) {
+	return json.Marshal(n)
+}
+
+// Unmarshal - decodes Node from json
+func (JSONCodec[V]) Unmarshal(data []byte) (*Node[V], error) {
+	var n Node[V]
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// GobCodec - is a Codec which encodes nodes with encoding/gob
+type GobCodec[V any] struct{}
+
+// Name - returns name of GobCodec
+func (GobCodec[V]) Name() string {
+	return "gob"
+}
+
+// Marshal - encodes Node with encoding/gob
+func (GobCodec[V]) Marshal(n *Node[V]) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(n); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal - decodes Node with encoding/gob
+func (GobCodec[V]) Unmarshal(data []byte) (*Node[V], error) {
+	var n Node[V]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&n); err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// BinaryCodec - is a compact Codec: every string and array is saved with its length before it.
+// Keys can be integers, floats (they are saved without losing precision), strings, []byte or bool
+type BinaryCodec[V any] struct{}
+
+// binary flags of Node
+const (
+	binaryLeaf byte = 1 << iota
+	binaryValues
+)
+
+// Name - returns name of BinaryCodec
+func (BinaryCodec[V]) Name() string {
+	return "binary"
+}
+
+// Marshal - encodes Node to binary format
+func (BinaryCodec[V]) Marshal(n *Node[V]) ([]byte, error) {
+	var flags byte
+	if n.Leaf {
+		flags |= binaryLeaf
+	}
+	if n.Values != nil {
+		flags |= binaryValues
+	}
+
+	buf := appendBinaryBytes(nil, []byte(n.Name))
+	buf = append(buf, flags)
+
+	buf = binary.AppendUvarint(buf, uint64(len(n.Keys)))
+	for _, k := range n.Keys {
+		var err error
+		if buf, err = appendBinaryKey(buf, reflect.ValueOf(k)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range n.Values {
+		buf = appendBinaryBytes(buf, v)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(n.Children)))
+	for i, c := range n.Children {
+		buf = appendBinaryBytes(buf, []byte(c))
+		buf = binary.AppendUvarint(buf, uint64(n.Counts[i]))
+	}
+
+	return buf, nil
+}
+
+// Unmarshal - decodes Node from binary format
+func (BinaryCodec[V]) Unmarshal(data []byte) (*Node[V], error) {
+	r := &binaryReader{data: data}
+
+	n := &Node[V]{Name: string(r.bytes())}
+	flags := r.byte()
+	n.Leaf = flags&binaryLeaf != 0
+
+	n.Keys = make([]V, r.length())
+	for i := range n.Keys {
+		r.key(reflect.ValueOf(&n.Keys[i]).Elem())
+	}
+
+	if flags&binaryValues != 0 {
+		n.Values = make([][]byte, len(n.Keys))
+		for i := range n.Values {
+			n.Values[i] = r.bytes()
+		}
+	}
+
+	n.Children = make([]string, r.length())
+	if len(n.Children) > 0 {
+		n.Counts = make([]int, len(n.Children))
+	}
+	for i := range n.Children {
+		n.Children[i] = string(r.bytes())
+		n.Counts[i] = int(r.uvarint())
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("can't decode Node: %w", r.err)
+	}
+
+	return n, nil
+}
+
+// appendBinaryBytes - internal function: appends length of b and b to buf
+func appendBinaryBytes(buf, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+
+	return append(buf, b...)
+}
+
+// appendBinaryKey - internal function: appends encoded key to buf
+func appendBinaryKey(buf []byte, k reflect.Value) ([]byte, error) {
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(buf, k.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(buf, k.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(k.Float())), nil
+	case reflect.String:
+		return appendBinaryBytes(buf, []byte(k.String())), nil
+	case reflect.Bool:
+		if k.Bool() {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case reflect.Slice:
+		if k.Type().Elem().Kind() == reflect.Uint8 {
+			return appendBinaryBytes(buf, k.Bytes()), nil
+		}
+	}
+
+	return nil, fmt.Errorf("binary codec doesn't support key type %s", k.Type())
+}
+
+// binaryReader - internal structure for reading binary format. The first error stops reading
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+// errBinaryTruncated - error of binary data which ends too early
+var errBinaryTruncated = errors.New("unexpected end of data")
+
+// uvarint - reads unsigned integer
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, l := binary.Uvarint(r.data)
+	if l <= 0 {
+		r.err = errBinaryTruncated
+		return 0
+	}
+	r.data = r.data[l:]
+
+	return v
+}
+
+// varint - reads signed integer
+func (r *binaryReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, l := binary.Varint(r.data)
+	if l <= 0 {
+		r.err = errBinaryTruncated
+		return 0
+	}
+	r.data = r.data[l:]
+
+	return v
+}
+
+// length - reads length of array. Length can't be greater than amount of remaining bytes
+func (r *binaryReader) length() int {
+	l := r.uvarint()
+	if l > uint64(len(r.data)) {
+		r.err = errBinaryTruncated
+		return 0
+	}
+
+	return int(l)
+}
+
+// byte - reads one byte
+func (r *binaryReader) byte() byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+
+	return b[0]
+}
+
+// bytes - reads array of bytes saved with its length
+func (r *binaryReader) bytes() []byte {
+	return bytes.Clone(r.next(r.length()))
+}
+
+// next - reads next l bytes
+func (r *binaryReader) next(l int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if l > len(r.data) {
+		r.err = errBinaryTruncated
+		return nil
+	}
+
+	b := r.data[:l]
+	r.data = r.data[l:]
+
+	return b
+}
+
+// key - reads key to k
+func (r *binaryReader) key(k reflect.Value) {
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		k.SetInt(r.varint())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		k.SetUint(r.uvarint())
+	case reflect.Float32, reflect.Float64:
+		if b := r.next(8); b != nil {
+			k.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}
+	case reflect.String:
+		k.SetString(string(r.bytes()))
+	case reflect.Bool:
+		k.SetBool(r.byte() != 0)
+	case reflect.Slice:
+		if k.Type().Elem().Kind() == reflect.Uint8 {
+			k.SetBytes(r.bytes())
+			return
+		}
+		fallthrough
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("binary codec doesn't support key type %s", k.Type())
+		}
+	}
+}
+
+// codecByName - internal function: returns built-in Codec by its name
+func codecByName[V any](name string) (Codec[V], error) {
+	switch name {
+	case JSONCodec[V]{}.Name():
+		return JSONCodec[V]{}, nil
+	case GobCodec[V]{}.Name():
+		return GobCodec[V]{}, nil
+	case BinaryCodec[V]{}.Name():
+		return BinaryCodec[V]{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown codec %s", name)
+}
@@ -0,0 +1,101 @@
+package btree
+
+import (
+	"math"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCodecs_float_keys(t1 *testing.T) {
+	n := &Node[float64]{
+		Name:     "1",
+		Keys:     []float64{0.1 + 0.2, math.MaxFloat64, math.SmallestNonzeroFloat64, -1e-300},
+		Values:   [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d")},
+		Children: []string{"2", "3", "4", "5", "6"},
+		Counts:   []int{1, 2, 3, 4, 5},
+		Leaf:     false,
+	}
+
+	for _, codec := range []Codec[float64]{JSONCodec[float64]{}, GobCodec[float64]{}, BinaryCodec[float64]{}} {
+		t1.Run(codec.Name(), func(t1 *testing.T) {
+			data, err := codec.Marshal(n)
+			if err != nil {
+				t1.Fatalf("Marshal() error = %v", err)
+			}
+			got, err := codec.Unmarshal(data)
+			if err != nil {
+				t1.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, n) {
+				t1.Errorf("Unmarshal() got = %+v, want %+v", got, n)
+			}
+		})
+	}
+}
+
+func TestBinaryCodec(t1 *testing.T) {
+	leaf := &Node[string]{
+		Name:     "0",
+		Keys:     []string{"A", "", "long key"},
+		Children: []string{},
+		Leaf:     true,
+	}
+
+	data, err := BinaryCodec[string]{}.Marshal(leaf)
+	if err != nil {
+		t1.Fatalf("Marshal() error = %v", err)
+	}
+	got, err := BinaryCodec[string]{}.Unmarshal(data)
+	if err != nil {
+		t1.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, leaf) {
+		t1.Errorf("Unmarshal() got = %+v, want %+v", got, leaf)
+	}
+
+	if _, err := (BinaryCodec[string]{}).Unmarshal(data[:len(data)-3]); err == nil {
+		t1.Errorf("Unmarshal() of truncated data expected error")
+	}
+
+	type point struct{ X, Y int }
+	if _, err := (BinaryCodec[point]{}).Marshal(&Node[point]{Keys: []point{{1, 2}}}); err == nil {
+		t1.Errorf("Marshal() of struct key expected error")
+	}
+}
+
+func TestDiskStorage_codecs(t1 *testing.T) {
+	for _, codec := range []Codec[int]{GobCodec[int]{}, BinaryCodec[int]{}} {
+		testFolder := "disk_codec_" + codec.Name()
+		t1.Run(testFolder, func(t1 *testing.T) {
+			defer os.RemoveAll(testFolder)
+
+			s, err := NewDiskStorageWithCodec[int](testFolder, 2, codec)
+			if err != nil {
+				t1.Fatalf("NewDiskStorageWithCodec() error = %v", err)
+			}
+			m, _ := NewMap[int, string](2, s)
+			for i := 0; i < 50; i++ {
+				m.Put(i, "v")
+			}
+
+			if _, err := os.Stat(testFolder + "/0." + codec.Name()); err != nil {
+				t1.Errorf("root file error = %v", err)
+			}
+
+			// codec is chosen by name saved in manifest
+			t, err := Open[int](testFolder, 2)
+			if err != nil {
+				t1.Fatalf("Open() error = %v", err)
+			}
+			checkTreeBalance(t, t1)
+			if ok, _ := t.Exists(42); !ok {
+				t1.Errorf("Exists(42) got = false, want true")
+			}
+
+			if _, err := OpenDiskStorageWithCodec[int](testFolder, 2, JSONCodec[int]{}); err == nil {
+				t1.Errorf("OpenDiskStorageWithCodec() with another codec expected error")
+			}
+		})
+	}
+}
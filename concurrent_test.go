@@ -0,0 +1,141 @@
+package btree
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTree_concurrent(t1 *testing.T) {
+	testFolder := "tree_concurrent"
+	defer os.RemoveAll(testFolder)
+
+	for _, name := range []string{"memory", "disk"} {
+		t1.Run(name, func(t1 *testing.T) {
+			var s NodeStorage[int]
+			if name == "memory" {
+				s, _ = NewMemoryStorage[int](name, 2)
+			} else {
+				s, _ = NewDiskStorage[int](testFolder, 2)
+			}
+			t, _ := NewTree[int](2, s)
+
+			const writers, keys = 4, 50
+			var wg sync.WaitGroup
+			for w := 0; w < writers; w++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for k := w * keys; k < (w+1)*keys; k++ {
+						if err := t.Insert(k); err != nil {
+							t1.Errorf("Insert(%d) error = %v", k, err)
+						}
+					}
+					for k := w * keys; k < (w+1)*keys; k += 2 {
+						if err := t.Delete(k); err != nil {
+							t1.Errorf("Delete(%d) error = %v", k, err)
+						}
+					}
+				}()
+
+				// readers always see a whole tree: keys are ordered
+				go func() {
+					defer wg.Done()
+					for i := 0; i < keys; i++ {
+						if _, err := t.Exists(i); err != nil {
+							t1.Errorf("Exists(%d) error = %v", i, err)
+						}
+
+						prev := -1
+						for k, err := range t.All() {
+							if err != nil {
+								t1.Errorf("All() error = %v", err)
+								break
+							}
+							if k <= prev {
+								t1.Errorf("All() got %d after %d", k, prev)
+							}
+							prev = k
+						}
+						if _, _, err := t.Max(); err != nil {
+							t1.Errorf("Max() error = %v", err)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			if t.Len() != writers*keys/2 {
+				t1.Errorf("Len() got = %v, want %v", t.Len(), writers*keys/2)
+			}
+			checkTreeBalance(t, t1)
+		})
+	}
+}
+
+func TestTree_All_concurrent_writer(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	for i := 0; i < 200; i++ {
+		t.Insert(i * 2)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				t.Insert(i*2 + 1)
+			}
+		}()
+
+		// body of loop reads Tree while writer waits for lock: walking continues in changed Tree
+		prev, even := -1, 0
+		for k, err := range t.All() {
+			if err != nil {
+				t1.Errorf("All() error = %v", err)
+				break
+			}
+			if k <= prev {
+				t1.Errorf("All() got %v after %v", k, prev)
+			}
+			prev = k
+			if ok, err := t.Exists(k); !ok || err != nil {
+				t1.Errorf("Exists(%d) got = %v, %v, want true", k, ok, err)
+			}
+			if k%2 == 0 {
+				even++
+			}
+		}
+		wg.Wait()
+		if even != 200 {
+			t1.Errorf("All() got %v even keys, want 200", even)
+		}
+
+		// body of loop changes Tree
+		for k := range t.Backward() {
+			if k < 390 {
+				break
+			}
+			t.Delete(k)
+		}
+		for k := range t.Range(0, 10) {
+			t.Insert(k + 1000)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t1.Fatalf("walking with reading and changing of Tree in body of loop is locked")
+	}
+	if t.Len() != 400 {
+		t1.Errorf("Len() got = %v, want 400", t.Len())
+	}
+	checkTreeBalance(t, t1)
+}
@@ -0,0 +1,254 @@
+package btree
+
+// Cursor is a structure for moving through keys of Tree in both directions.
+// Cursor keeps a stack of nodes from root to current position, so every step reads only nodes
+// which weren't visited before. Every step locks Tree for reading. If Tree was changed after Cursor was positioned,
+// the stack is built again from current key, so Next and Prev move to neighbours of this key in changed Tree
+// (even if current key was deleted)
+type Cursor[V any] struct {
+	tree    *Tree[V]
+	stack   []pathFrame[V] // index of the last frame is a position of current key
+	version uint64         // version of Tree when the stack was built
+	err     error
+}
+
+// Cursor is a function for creating Cursor of Tree. New Cursor isn't positioned: call First, Last or Seek
+func (t *Tree[V]) Cursor() *Cursor[V] {
+	return &Cursor[V]{tree: t}
+}
+
+// First - moves Cursor to the min key of Tree. Returns false if Tree is empty or an error happened
+func (c *Cursor[V]) First() bool {
+	c.tree.mu.RLock()
+	defer c.tree.mu.RUnlock()
+
+	c.reset()
+
+	return c.pushLeftmost(c.tree.root)
+}
+
+// Last - moves Cursor to the max key of Tree. Returns false if Tree is empty or an error happened
+func (c *Cursor[V]) Last() bool {
+	c.tree.mu.RLock()
+	defer c.tree.mu.RUnlock()
+
+	c.reset()
+
+	return c.pushRightmost(c.tree.root)
+}
+
+// Seek - moves Cursor to the first key which is greater or equal than k.
+// Returns false if there is no such key or an error happened
+func (c *Cursor[V]) Seek(k V) bool {
+	c.tree.mu.RLock()
+	defer c.tree.mu.RUnlock()
+
+	return c.seek(k)
+}
+
+// seek - internal function for moving Cursor to the first key which is greater or equal than k
+func (c *Cursor[V]) seek(k V) bool {
+	c.reset()
+
+	name := c.tree.root
+	for {
+		n, ok := c.read(name)
+		if !ok {
+			return false
+		}
+
+		i := 0
+		for i < len(n.Keys) && c.tree.compare(k, n.Keys[i]) > 0 {
+			i++
+		}
+		c.stack = append(c.stack, pathFrame[V]{node: n, i: i})
+
+		if i < len(n.Keys) && c.tree.compare(k, n.Keys[i]) == 0 {
+			return true
+		}
+		if n.Leaf {
+			if i < len(n.Keys) {
+				return true
+			}
+			return c.upNext()
+		}
+
+		name = n.Children[i]
+	}
+}
+
+// Next - moves Cursor to the next key. Returns false if there is no next key or an error happened
+func (c *Cursor[V]) Next() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	c.tree.mu.RLock()
+	defer c.tree.mu.RUnlock()
+
+	if c.version != c.tree.version {
+		// Tree was changed: the next key is current key itself if it's still in Tree, else it's found by seek
+		k := c.Key()
+		if !c.seek(k) || c.tree.compare(c.Key(), k) > 0 {
+			return c.Valid()
+		}
+	}
+
+	top := &c.stack[len(c.stack)-1]
+	if !top.node.Leaf {
+		top.i++
+		return c.pushLeftmost(top.node.Children[top.i])
+	}
+
+	top.i++
+	if top.i < len(top.node.Keys) {
+		return true
+	}
+
+	return c.upNext()
+}
+
+// Prev - moves Cursor to the previous key. Returns false if there is no previous key or an error happened
+func (c *Cursor[V]) Prev() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	c.tree.mu.RLock()
+	defer c.tree.mu.RUnlock()
+
+	if c.version != c.tree.version {
+		// Tree was changed: the previous key is before the first key which is greater or equal than current one
+		if !c.seek(c.Key()) {
+			if c.err != nil {
+				return false
+			}
+			return c.pushRightmost(c.tree.root)
+		}
+	}
+
+	top := &c.stack[len(c.stack)-1]
+	if !top.node.Leaf {
+		return c.pushRightmost(top.node.Children[top.i])
+	}
+
+	top.i--
+	if top.i >= 0 {
+		return true
+	}
+
+	return c.upPrev()
+}
+
+// Valid - returns true if Cursor is positioned on a key
+func (c *Cursor[V]) Valid() bool {
+	return len(c.stack) > 0
+}
+
+// Key - returns key on the current position of Cursor. If Cursor isn't valid - returns zero value
+func (c *Cursor[V]) Key() V {
+	if !c.Valid() {
+		var k V
+		return k
+	}
+
+	top := c.stack[len(c.stack)-1]
+
+	return top.node.Keys[top.i]
+}
+
+// Err - returns an error which happened while Cursor was moving
+func (c *Cursor[V]) Err() error {
+	return c.err
+}
+
+// reset - internal function for clearing position of Cursor. New position is built for current version of Tree
+func (c *Cursor[V]) reset() {
+	c.stack = c.stack[:0]
+	c.version = c.tree.version
+	c.err = nil
+}
+
+// read - internal function for reading Node from storage. In case of error Cursor becomes invalid
+func (c *Cursor[V]) read(name string) (*Node[V], bool) {
+	n, err := c.tree.storage.Read(name)
+	if err != nil {
+		c.err = err
+		c.stack = c.stack[:0]
+		return nil, false
+	}
+
+	return n, true
+}
+
+// pushLeftmost - internal function for moving to the min key of subtree
+func (c *Cursor[V]) pushLeftmost(name string) bool {
+	for {
+		n, ok := c.read(name)
+		if !ok {
+			return false
+		}
+		c.stack = append(c.stack, pathFrame[V]{node: n, i: 0})
+
+		if n.Leaf {
+			if len(n.Keys) == 0 {
+				c.stack = c.stack[:0]
+				return false
+			}
+			return true
+		}
+
+		name = n.Children[0]
+	}
+}
+
+// pushRightmost - internal function for moving to the max key of subtree
+func (c *Cursor[V]) pushRightmost(name string) bool {
+	for {
+		n, ok := c.read(name)
+		if !ok {
+			return false
+		}
+
+		if n.Leaf {
+			c.stack = append(c.stack, pathFrame[V]{node: n, i: len(n.Keys) - 1})
+			if len(n.Keys) == 0 {
+				c.stack = c.stack[:0]
+				return false
+			}
+			return true
+		}
+
+		c.stack = append(c.stack, pathFrame[V]{node: n, i: len(n.Children) - 1})
+		name = n.Children[len(n.Children)-1]
+	}
+}
+
+// upNext - internal function: goes up from finished subtree to the next key in ancestors
+func (c *Cursor[V]) upNext() bool {
+	c.stack = c.stack[:len(c.stack)-1]
+	for len(c.stack) > 0 {
+		top := c.stack[len(c.stack)-1]
+		if top.i < len(top.node.Keys) {
+			return true
+		}
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+
+	return false
+}
+
+// upPrev - internal function: goes up from finished subtree to the previous key in ancestors
+func (c *Cursor[V]) upPrev() bool {
+	c.stack = c.stack[:len(c.stack)-1]
+	for len(c.stack) > 0 {
+		top := &c.stack[len(c.stack)-1]
+		if top.i > 0 {
+			top.i--
+			return true
+		}
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+
+	return false
+}
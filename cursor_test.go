@@ -0,0 +1,153 @@
+package btree
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCursor_Next_Prev(t1 *testing.T) {
+	testFolder := "cursor_next_prev"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{8, 3, 15, 1, 12, 6, 4, 20, 9, 17, 2, 11}, testFolder)
+
+	c := t.Cursor()
+	var got []int
+	for ok := c.First(); ok; ok = c.Next() {
+		got = append(got, c.Key())
+	}
+	if want := []int{1, 2, 3, 4, 6, 8, 9, 11, 12, 15, 17, 20}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("First/Next got = %v, want %v", got, want)
+	}
+
+	got = nil
+	for ok := c.Last(); ok; ok = c.Prev() {
+		got = append(got, c.Key())
+	}
+	if want := []int{20, 17, 15, 12, 11, 9, 8, 6, 4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("Last/Prev got = %v, want %v", got, want)
+	}
+
+	// change direction in the middle of the tree
+	c.Seek(8)
+	got = []int{c.Key()}
+	for _, next := range []bool{true, true, false, false, false} {
+		if next {
+			c.Next()
+		} else {
+			c.Prev()
+		}
+		got = append(got, c.Key())
+	}
+	if want := []int{8, 9, 11, 9, 8, 6}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("Next/Prev got = %v, want %v", got, want)
+	}
+	if c.Err() != nil {
+		t1.Errorf("Err() = %v", c.Err())
+	}
+}
+
+func TestCursor_Seek(t1 *testing.T) {
+	testFolder := "cursor_seek"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{8, 3, 15, 1, 12, 6, 4, 20, 9, 17, 2, 11}, testFolder)
+
+	type testCase struct {
+		name   string
+		k      int
+		want   int
+		wantOk bool
+	}
+	tests := []testCase{
+		{name: "existing_key_in_leaf", k: 11, want: 11, wantOk: true},
+		{name: "existing_key_in_root", k: 15, want: 15, wantOk: true},
+		{name: "between_keys", k: 5, want: 6, wantOk: true},
+		{name: "after_last_key_of_leaf", k: 7, want: 8, wantOk: true},
+		{name: "before_min", k: -5, want: 1, wantOk: true},
+		{name: "after_max", k: 21, want: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			c := t.Cursor()
+			ok := c.Seek(tt.k)
+			if ok != tt.wantOk || c.Key() != tt.want {
+				t1.Errorf("Seek(%d) got = %v, %v, want %v, %v", tt.k, c.Key(), ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestCursor_empty_tree(t1 *testing.T) {
+	testFolder := "cursor_empty_tree"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{}, testFolder)
+
+	c := t.Cursor()
+	if c.First() || c.Last() || c.Seek(1) || c.Next() || c.Prev() {
+		t1.Errorf("Cursor of empty tree should be invalid")
+	}
+}
+
+func TestCursor_changed_tree(t1 *testing.T) {
+	for _, forward := range []bool{true, false} {
+		t1.Run(map[bool]string{true: "next", false: "prev"}[forward], func(t1 *testing.T) {
+			ms, _ := NewMemoryStorage[int]("memory", 2)
+			t, _ := NewTree[int](2, ms)
+			model := make(map[int]bool)
+			for i := 0; i < 200; i += 2 {
+				t.Insert(i)
+				model[i] = true
+			}
+
+			// keys are inserted and deleted (with splits and merges of nodes) between steps of Cursor
+			r := rand.New(rand.NewSource(1))
+			c := t.Cursor()
+			ok := c.First()
+			if !forward {
+				ok = c.Last()
+			}
+			for ok {
+				k := c.Key()
+				for j := 0; j < 5; j++ {
+					x := r.Intn(200)
+					if model[x] {
+						t.Delete(x)
+					} else {
+						t.Insert(x)
+					}
+					model[x] = !model[x]
+				}
+
+				if forward {
+					ok = c.Next()
+				} else {
+					ok = c.Prev()
+				}
+				want, wantOk := neighbourKey(model, k, forward)
+				if ok != wantOk || ok && c.Key() != want {
+					t1.Fatalf("step after %d got = %v, %v, want %v, %v", k, c.Key(), ok, want, wantOk)
+				}
+			}
+			if c.Err() != nil {
+				t1.Errorf("Err() = %v", c.Err())
+			}
+		})
+	}
+}
+
+// neighbourKey - returns the min key of set which is greater than k (or the max key which is less than k)
+func neighbourKey(set map[int]bool, k int, greater bool) (int, bool) {
+	var n int
+	found := false
+	for x, ok := range set {
+		if !ok || greater && x <= k || !greater && x >= k {
+			continue
+		}
+		if !found || greater && x < n || !greater && x > n {
+			n, found = x, true
+		}
+	}
+
+	return n, found
+}
@@ -3,25 +3,74 @@ package btree
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// metaFileName - is a name of file with Tree's metadata in DiskStorage
+	metaFileName = "meta"
+	// manifestFileName - is a name of file with parameters of Tree in DiskStorage
+	manifestFileName = "manifest"
+	// batchFileName - is a name of file with list of changes of committed Batch which weren't finished yet
+	batchFileName = "batch"
+	// refsFileName - is a name of file with amounts of references to shared nodes in DiskStorage
+	refsFileName = "refs"
 
-	"golang.org/x/exp/constraints"
+	// pendingExt - is an extension of Node's file written by Batch which isn't finished yet
+	pendingExt = ".pending"
+	// tempPattern - is a pattern of temporary files' names. File is renamed to its name after writing
+	tempPattern = ".tmp-*"
 )
 
-// DiskStorage - is a storage for keeping files of Tree. Format of files in this realisation - json
+// diskBatch - internal structure of committed Batch saved in DiskStorage: names of written and deleted nodes,
+// new metadata and new amounts of references
+type diskBatch struct {
+	Writes  []string
+	Deletes []string
+	Meta    *Meta          `json:",omitempty"`
+	Refs    map[string]int `json:",omitempty"`
+}
+
+// DiskStorage - is a storage for keeping files of Tree. Every Node is saved in its own file encoded by Codec
+// (json by default). Metadata, manifest and amounts of references to shared nodes are saved in json files.
+// Every file is written to temporary file, synced and renamed, so a crash never leaves a truncated file.
+// Batch is applied atomically: its nodes are written to pending files, then list of changes is saved
+// and pending files are renamed. Opening of storage finishes saved changes or removes pending files
 // - param folderName is a name of folder where will be saved files of tree
-type DiskStorage[V constraints.Ordered] struct {
+type DiskStorage[V any] struct {
 	folderName string
+	codec      Codec[V]
+
+	mu      sync.Mutex
+	lastID  int        // the last allocated Node's id. Names of nodes are string ids
+	pending *diskBatch // committed Batch which wasn't finished because of an error, it's finished before next calls
 }
 
 // NewDiskStorage - function for creating of DiskStorage
 // - param folderName is name of folder where will be saved files of tree
 // - param t is a min degree of b-tree. It can't be less than 2
-func NewDiskStorage[V constraints.Ordered](folderName string, t int) (*DiskStorage[V], error) {
+func NewDiskStorage[V any](folderName string, t int) (*DiskStorage[V], error) {
+	return NewDiskStorageWithCodec[V](folderName, t, JSONCodec[V]{})
+}
+
+// NewDiskStorageWithCodec - function for creating of DiskStorage which encodes nodes by codec
+// - param folderName is name of folder where will be saved files of tree
+// - param t is a min degree of b-tree. It can't be less than 2
+// - param codec is an encoding of nodes. Its name is saved in manifest
+func NewDiskStorageWithCodec[V any](folderName string, t int, codec Codec[V]) (*DiskStorage[V], error) {
 	if t < 2 {
 		return nil, errors.New("t can't be less than 2")
 	}
 
+	if codec == nil {
+		return nil, errors.New("codec can't be nil")
+	}
+
 	if err := os.Mkdir(folderName, os.ModePerm); err != nil {
 		return nil, err
 	}
@@ -29,12 +78,84 @@ func NewDiskStorage[V constraints.Ordered](folderName string, t int) (*DiskStora
 	root := NewNode[V](t, RootName)
 	s := &DiskStorage[V]{
 		folderName: folderName,
+		codec:      codec,
 	}
 
 	if err := s.Write(root); err != nil {
 		return nil, err
 	}
 
+	if err := s.WriteMeta(emptyMeta()); err != nil {
+		return nil, err
+	}
+
+	m := newManifest[V](t)
+	m.Codec = codec.Name()
+	if err := s.writeManifest(m); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// OpenDiskStorage - function for opening of DiskStorage with a tree which was saved earlier.
+// Nodes are decoded by built-in codec which name is saved in manifest
+// - param folderName is name of folder where files of tree are saved
+// - param t is a min degree of b-tree. It should be the same as t of saved tree
+func OpenDiskStorage[V any](folderName string, t int) (*DiskStorage[V], error) {
+	return OpenDiskStorageWithCodec[V](folderName, t, nil)
+}
+
+// OpenDiskStorageWithCodec - function for opening of DiskStorage with a tree which was saved earlier
+// - param folderName is name of folder where files of tree are saved
+// - param t is a min degree of b-tree. It should be the same as t of saved tree
+// - param codec is an encoding of nodes. Its name should be the same as name saved in manifest.
+// If codec is nil, built-in codec is chosen by saved name
+func OpenDiskStorageWithCodec[V any](folderName string, t int, codec Codec[V]) (*DiskStorage[V], error) {
+	info, err := os.Stat(folderName)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a folder", folderName)
+	}
+
+	s := &DiskStorage[V]{
+		folderName: folderName,
+	}
+
+	m, err := s.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("can't read manifest of tree in %s: %w", folderName, err)
+	}
+
+	if err = checkManifest[V](m, t); err != nil {
+		return nil, fmt.Errorf("tree in %s: %w", folderName, err)
+	}
+
+	// storages without saved codec's name keep nodes in json
+	if m.Codec == "" {
+		m.Codec = JSONCodec[V]{}.Name()
+	}
+
+	if codec == nil {
+		if codec, err = codecByName[V](m.Codec); err != nil {
+			return nil, fmt.Errorf("tree in %s: %w", folderName, err)
+		}
+	}
+	if m.Codec != codec.Name() {
+		return nil, fmt.Errorf("tree in %s is saved by codec %s, but %s was requested", folderName, m.Codec, codec.Name())
+	}
+	s.codec = codec
+
+	if err = s.recover(); err != nil {
+		return nil, fmt.Errorf("can't recover tree in %s: %w", folderName, err)
+	}
+
+	if s.lastID, err = s.maxID(); err != nil {
+		return nil, err
+	}
+
 	return s, nil
 }
 
@@ -43,44 +164,414 @@ func (fs *DiskStorage[V]) Name() string {
 	return fs.folderName
 }
 
+// AllocName - function for allocating name for new Node.
+// Names are increasing ids, so a name is never reused while Node with it exists
+func (fs *DiskStorage[V]) AllocName() (string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.lastID++
+
+	return strconv.Itoa(fs.lastID), nil
+}
+
 // Read - function for reading Node by name from DiskStorage
 // - param name - is name of Node file
 func (fs *DiskStorage[V]) Read(name string) (*Node[V], error) {
-	data, err := os.ReadFile(fs.filePath(name))
+	fs.mu.Lock()
+	err := fs.finishPending()
+	fs.mu.Unlock()
 	if err != nil {
 		return nil, err
 	}
 
-	var n Node[V]
-	if err = json.Unmarshal(data, &n); err != nil {
+	data, err := os.ReadFile(fs.filePath(name))
+	if err != nil {
 		return nil, err
 	}
 
-	return &n, nil
+	return fs.codec.Unmarshal(data)
 }
 
-// Write - function for writing Node to DiskStorage
+// Write - function for writing Node to DiskStorage.
+// Name of written Node won't be allocated again even if it wasn't allocated by this storage (e.g. Node is restored from log)
 func (fs *DiskStorage[V]) Write(n *Node[V]) error {
-	jsonData, err := json.Marshal(n)
+	data, err := fs.codec.Marshal(n)
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(fs.filePath(n.Name), jsonData, os.ModePerm)
-	if err != nil {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err = fs.finishPending(); err != nil {
 		return err
 	}
+	if id, err := strconv.Atoi(n.Name); err == nil {
+		fs.lastID = max(fs.lastID, id)
+	}
 
-	return nil
+	return fs.writeFile(fs.filePath(n.Name), data)
 }
 
 // Delete - function for deleting Node from DiskStorage
 // param name - is name of Node file
 func (fs *DiskStorage[V]) Delete(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.finishPending(); err != nil {
+		return err
+	}
+
 	return os.Remove(fs.filePath(name))
 }
 
-// filePath - this function returns filePath of Node in DiskStorage
+// ReadMeta - function for reading Tree's metadata from DiskStorage
+func (fs *DiskStorage[V]) ReadMeta() (Meta, error) {
+	fs.mu.Lock()
+	err := fs.finishPending()
+	fs.mu.Unlock()
+	var m Meta
+	if err != nil {
+		return m, err
+	}
+
+	data, err := os.ReadFile(fs.jsonFilePath(metaFileName))
+	if err != nil {
+		return m, err
+	}
+
+	err = json.Unmarshal(data, &m)
+
+	return m, err
+}
+
+// WriteMeta - function for writing Tree's metadata to DiskStorage
+func (fs *DiskStorage[V]) WriteMeta(m Meta) error {
+	jsonData, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err = fs.finishPending(); err != nil {
+		return err
+	}
+
+	return fs.writeFile(fs.jsonFilePath(metaFileName), jsonData)
+}
+
+// Manifest - function for reading parameters of Tree saved in DiskStorage
+func (fs *DiskStorage[V]) Manifest() (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(fs.jsonFilePath(manifestFileName))
+	if err != nil {
+		return m, err
+	}
+
+	err = json.Unmarshal(data, &m)
+
+	return m, err
+}
+
+// ReadRefs - function for reading amounts of references to shared nodes from DiskStorage
+func (fs *DiskStorage[V]) ReadRefs() (map[string]int, error) {
+	refs := make(map[string]int)
+	data, err := os.ReadFile(fs.jsonFilePath(refsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &refs)
+
+	return refs, err
+}
+
+// writeRefs - internal function for saving amounts of references changed by committed Batch.
+// Amount 1 isn't saved, deleted nodes lose their amounts
+func (fs *DiskStorage[V]) writeRefs(db diskBatch) error {
+	refs, err := fs.ReadRefs()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for name, c := range db.Refs {
+		if old, ok := refs[name]; c == 1 && ok || c != 1 && old != c {
+			changed = true
+		}
+		if c == 1 {
+			delete(refs, name)
+		} else {
+			refs[name] = c
+		}
+	}
+	for _, name := range db.Deletes {
+		if _, ok := refs[name]; ok {
+			changed = true
+			delete(refs, name)
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	jsonData, err := json.Marshal(refs)
+	if err != nil {
+		return err
+	}
+
+	return fs.writeFile(fs.jsonFilePath(refsFileName), jsonData)
+}
+
+// writeManifest - internal function for writing manifest of DiskStorage
+func (fs *DiskStorage[V]) writeManifest(m Manifest) error {
+	jsonData, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return fs.writeFile(fs.jsonFilePath(manifestFileName), jsonData)
+}
+
+// Apply - function for applying Batch to DiskStorage atomically.
+// Nodes are written to pending files, then list of changes is saved: after it Batch is committed
+// and will be finished by OpenDiskStorage if a crash happens. If finishing fails, it's repeated by the next call
+// of storage, new Batch isn't accepted until committed one is finished
+func (fs *DiskStorage[V]) Apply(b Batch[V]) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.finishPending(); err != nil {
+		return err
+	}
+
+	db, err := fs.commitBatch(b)
+	if err != nil {
+		return err
+	}
+
+	if err = fs.finishBatch(db); err != nil {
+		fs.pending = &db
+		return err
+	}
+
+	return nil
+}
+
+// finishPending - internal function for finishing committed Batch which wasn't finished because of an error.
+// Until it's finished, storage is half-changed and every call returns an error
+func (fs *DiskStorage[V]) finishPending() error {
+	if fs.pending == nil {
+		return nil
+	}
+	if err := fs.finishBatch(*fs.pending); err != nil {
+		return fmt.Errorf("committed batch isn't finished: %w", err)
+	}
+	fs.pending = nil
+
+	return nil
+}
+
+// commitBatch - internal function for writing nodes of Batch to pending files and saving list of changes
+func (fs *DiskStorage[V]) commitBatch(b Batch[V]) (diskBatch, error) {
+	db := diskBatch{Deletes: b.Deletes, Meta: b.Meta, Refs: b.Refs}
+	for _, n := range b.Writes {
+		data, err := fs.codec.Marshal(n)
+		if err != nil {
+			return db, err
+		}
+		if err = writeSyncedFile(fs.filePath(n.Name)+pendingExt, data); err != nil {
+			return db, err
+		}
+		db.Writes = append(db.Writes, n.Name)
+	}
+
+	jsonData, err := json.Marshal(db)
+	if err != nil {
+		return db, err
+	}
+
+	return db, fs.writeFile(fs.jsonFilePath(batchFileName), jsonData)
+}
+
+// recover - internal function: finishes committed Batch and removes files of not committed ones
+func (fs *DiskStorage[V]) recover() error {
+	data, err := os.ReadFile(fs.jsonFilePath(batchFileName))
+	if err == nil {
+		var db diskBatch
+		if err = json.Unmarshal(data, &db); err != nil {
+			return err
+		}
+		if err = fs.finishBatch(db); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	for _, pattern := range []string{"*" + pendingExt, tempPattern} {
+		files, err := filepath.Glob(filepath.Join(fs.folderName, pattern))
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err = os.Remove(f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// finishBatch - internal function for moving pending files of committed Batch to their places,
+// deleting nodes and writing metadata and references. It can be repeated if it was interrupted
+func (fs *DiskStorage[V]) finishBatch(db diskBatch) error {
+	for _, name := range db.Writes {
+		err := os.Rename(fs.filePath(name)+pendingExt, fs.filePath(name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	for _, name := range db.Deletes {
+		if err := os.Remove(fs.filePath(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if db.Meta != nil {
+		jsonData, err := json.Marshal(*db.Meta)
+		if err != nil {
+			return err
+		}
+		if err = fs.writeFile(fs.jsonFilePath(metaFileName), jsonData); err != nil {
+			return err
+		}
+	}
+
+	if err := fs.writeRefs(db); err != nil {
+		return err
+	}
+
+	if err := syncFolder(fs.folderName); err != nil {
+		return err
+	}
+	if err := os.Remove(fs.jsonFilePath(batchFileName)); err != nil {
+		return err
+	}
+
+	return syncFolder(fs.folderName)
+}
+
+// writeFile - internal function for writing file atomically: data is written to temporary file,
+// synced and renamed to path
+func (fs *DiskStorage[V]) writeFile(path string, data []byte) error {
+	f, err := os.CreateTemp(fs.folderName, tempPattern)
+	if err != nil {
+		return err
+	}
+
+	err = f.Chmod(0o666)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return syncFolder(fs.folderName)
+}
+
+// maxID - internal function: returns max id of Node saved in DiskStorage
+func (fs *DiskStorage[V]) maxID() (int, error) {
+	entries, err := os.ReadDir(fs.folderName)
+	if err != nil {
+		return 0, err
+	}
+
+	maxID := 0
+	for _, e := range entries {
+		name, _, _ := strings.Cut(e.Name(), ".")
+		id, err := strconv.Atoi(name)
+		if err == nil && id > maxID {
+			maxID = id
+		}
+	}
+
+	return maxID, nil
+}
+
+// nodeNames - internal function: returns names of all nodes saved in DiskStorage
+func (fs *DiskStorage[V]) nodeNames() ([]string, error) {
+	entries, err := os.ReadDir(fs.folderName)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), "."+fs.codec.Name())
+		if _, err := strconv.Atoi(name); ok && err == nil {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// filePath - this function returns filePath of Node in DiskStorage. Extension of file is a name of codec
 func (fs *DiskStorage[V]) filePath(name string) string {
+	return fs.folderName + "/" + name + "." + fs.codec.Name()
+}
+
+// jsonFilePath - this function returns filePath of json file in DiskStorage
+func (fs *DiskStorage[V]) jsonFilePath(name string) string {
 	return fs.folderName + "/" + name + ".json"
 }
+
+// writeSyncedFile - internal function for writing file and syncing it to disk
+func writeSyncedFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// syncFolder - internal function for syncing folder, so created, renamed and removed files are saved to disk
+func syncFolder(folder string) error {
+	f, err := os.Open(folder)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
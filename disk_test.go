@@ -0,0 +1,182 @@
+package btree
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpen(t1 *testing.T) {
+	testFolder := "open_tree"
+	defer os.RemoveAll(testFolder)
+	createIntTreeStorage(3, []int{5, 1, 9, 3, 7, 2, 8}, testFolder)
+
+	t, err := Open[int](testFolder, 3)
+	if err != nil {
+		t1.Fatalf("Open() error = %v", err)
+	}
+	if t.Len() != 7 {
+		t1.Errorf("Len() got = %v, want 7", t.Len())
+	}
+
+	var got []int
+	t.Ascend(func(k int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := []int{1, 2, 3, 5, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("Ascend() got = %v, want %v", got, want)
+	}
+
+	if err := t.Insert(4); err != nil {
+		t1.Errorf("Insert() error = %v", err)
+	}
+	if ok, _ := t.Exists(4); !ok {
+		t1.Errorf("Exists(4) got = false, want true")
+	}
+}
+
+func TestOpen_wrong_parameters(t1 *testing.T) {
+	testFolder := "open_tree_wrong_parameters"
+	defer os.RemoveAll(testFolder)
+	createIntTreeStorage(3, []int{5, 1, 9}, testFolder)
+
+	if _, err := Open[int](testFolder, 4); err == nil {
+		t1.Errorf("Open() with another t expected error")
+	}
+	if _, err := Open[string](testFolder, 3); err == nil {
+		t1.Errorf("Open() with another key type expected error")
+	}
+	if _, err := Open[int]("not_existing_folder", 3); err == nil {
+		t1.Errorf("Open() of not existing folder expected error")
+	}
+}
+
+func TestDiskStorage_Manifest(t1 *testing.T) {
+	testFolder := "disk_manifest"
+	defer os.RemoveAll(testFolder)
+
+	s, err := NewDiskStorage[string](testFolder, 3)
+	if err != nil {
+		t1.Fatalf("NewDiskStorage() error = %v", err)
+	}
+
+	m, err := s.Manifest()
+	if err != nil {
+		t1.Fatalf("Manifest() error = %v", err)
+	}
+	if m.Version != ManifestVersion || m.T != 3 || m.KeyType != "string" || m.Created.IsZero() {
+		t1.Errorf("Manifest() got = %+v", m)
+	}
+
+	if _, err := NewTree[string](4, s); err == nil {
+		t1.Errorf("NewTree() with another t expected error")
+	}
+	if _, err := NewTree[string](3, s); err != nil {
+		t1.Errorf("NewTree() error = %v", err)
+	}
+
+	m.Version = ManifestVersion + 1
+	s.writeManifest(m)
+	if _, err := NewTree[string](3, s); err == nil {
+		t1.Errorf("NewTree() with unsupported manifest version expected error")
+	}
+}
+
+func TestDiskStorage_recover(t1 *testing.T) {
+	testFolder := "disk_recover"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(2, []int{5, 1, 9, 3, 7, 2, 8, 4, 6, 0}, testFolder)
+	s := t.storage.(*DiskStorage[int])
+
+	// crash after Batch was committed: changes are finished on opening
+	b := collectBatch(t, func(t *Tree[int]) error {
+		return t.insert(10, nil)
+	})
+	if _, err := s.commitBatch(b); err != nil {
+		t1.Fatalf("commitBatch() error = %v", err)
+	}
+
+	t, err := Open[int](testFolder, 2)
+	if err != nil {
+		t1.Fatalf("Open() error = %v", err)
+	}
+	if ok, _ := t.Exists(10); !ok || t.Len() != 11 {
+		t1.Errorf("Exists(10), Len() got = %v, %v, want true, 11", ok, t.Len())
+	}
+	checkTreeBalance(t, t1)
+	if _, err := os.Stat(testFolder + "/batch.json"); !os.IsNotExist(err) {
+		t1.Errorf("batch file wasn't removed: %v", err)
+	}
+
+	// crash before Batch was committed: pending files are removed
+	b = collectBatch(t, func(t *Tree[int]) error {
+		_, err := t.delete(5)
+		return err
+	})
+	for _, n := range b.Writes {
+		data, _ := s.codec.Marshal(n)
+		writeSyncedFile(s.filePath(n.Name)+pendingExt, data)
+	}
+
+	t, err = Open[int](testFolder, 2)
+	if err != nil {
+		t1.Fatalf("Open() error = %v", err)
+	}
+	if ok, _ := t.Exists(5); !ok || t.Len() != 11 {
+		t1.Errorf("Exists(5), Len() got = %v, %v, want true, 11", ok, t.Len())
+	}
+	checkTreeBalance(t, t1)
+	if files, _ := filepath.Glob(testFolder + "/*" + pendingExt); len(files) != 0 {
+		t1.Errorf("pending files weren't removed: %v", files)
+	}
+}
+
+func TestDiskStorage_Apply_failed(t1 *testing.T) {
+	testFolder := "disk_apply_failed"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(2, makeRange(0, 20), testFolder)
+	s := t.storage.(*DiskStorage[int])
+
+	// finishing of committed Batch fails after some nodes were renamed
+	b := collectBatch(t, func(t *Tree[int]) error {
+		for k := 20; k < 30; k++ {
+			if err := t.insert(k, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	blocked := s.filePath(b.Writes[len(b.Writes)-1].Name)
+	os.Remove(blocked)
+	os.Mkdir(blocked, os.ModePerm)
+	if err := s.Apply(b); err == nil {
+		t1.Fatalf("Apply() error = nil, want error")
+	}
+
+	// storage refuses new changes and reading until committed Batch is finished
+	if err := s.Apply(Batch[int]{Deletes: []string{RootName}}); err == nil {
+		t1.Errorf("Apply() of the next batch error = nil, want error")
+	}
+	if _, err := s.Read(RootName); err == nil {
+		t1.Errorf("Read() error = nil, want error")
+	}
+	if _, err := os.Stat(s.jsonFilePath(batchFileName)); err != nil {
+		t1.Errorf("batch file of committed batch got error %v", err)
+	}
+
+	// committed Batch is finished by the next call when error is gone
+	os.Remove(blocked)
+	if _, err := s.Read(RootName); err != nil {
+		t1.Fatalf("Read() error = %v", err)
+	}
+	t, err := Open[int](testFolder, 2)
+	if err != nil {
+		t1.Fatalf("Open() error = %v", err)
+	}
+	checkTreeBalance(t, t1)
+	if got := collectKeys(t.All()); !reflect.DeepEqual(got, makeRange(0, 30)) {
+		t1.Errorf("All() got = %v", got)
+	}
+}
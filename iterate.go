@@ -0,0 +1,118 @@
+package btree
+
+import "iter"
+
+// Ascend is a function for walking through all keys of Tree in ascending order.
+// Walking stops when fn returns false. Tree isn't locked while fn runs, so fn can call methods of Tree.
+// If Tree is changed while walking, walking continues from the last key in changed Tree (see Cursor)
+func (t *Tree[V]) Ascend(fn func(k V) bool) error {
+	return t.ascendRange(nil, nil, fn)
+}
+
+// Descend is a function for walking through all keys of Tree in descending order.
+// Walking stops when fn returns false. Tree isn't locked while fn runs, so fn can call methods of Tree.
+// If Tree is changed while walking, walking continues from the last key in changed Tree (see Cursor)
+func (t *Tree[V]) Descend(fn func(k V) bool) error {
+	return t.descendRange(nil, nil, fn)
+}
+
+// AscendRange is a function for walking through keys of Tree in range [from, to) in ascending order.
+// Walking stops when fn returns false. Tree isn't locked while fn runs, so fn can call methods of Tree.
+// If Tree is changed while walking, walking continues from the last key in changed Tree (see Cursor)
+func (t *Tree[V]) AscendRange(from, to V, fn func(k V) bool) error {
+	return t.ascendRange(&from, &to, fn)
+}
+
+// DescendRange is a function for walking through keys of Tree in range (to, from] in descending order.
+// Walking stops when fn returns false. Tree isn't locked while fn runs, so fn can call methods of Tree.
+// If Tree is changed while walking, walking continues from the last key in changed Tree (see Cursor)
+func (t *Tree[V]) DescendRange(from, to V, fn func(k V) bool) error {
+	return t.descendRange(&from, &to, fn)
+}
+
+// ascendRange - internal function for ascending walk by Cursor. Nil from or to means that range is unbounded.
+// Tree is locked for reading only while Cursor moves, not while fn runs
+func (t *Tree[V]) ascendRange(from, to *V, fn func(k V) bool) error {
+	c := t.Cursor()
+	var ok bool
+	if from != nil {
+		ok = c.Seek(*from)
+	} else {
+		ok = c.First()
+	}
+
+	for ; ok; ok = c.Next() {
+		k := c.Key()
+		if to != nil && t.compare(k, *to) >= 0 || !fn(k) {
+			break
+		}
+	}
+
+	return c.Err()
+}
+
+// descendRange - internal function for descending walk by Cursor. Nil from or to means that range is unbounded.
+// Tree is locked for reading only while Cursor moves, not while fn runs
+func (t *Tree[V]) descendRange(from, to *V, fn func(k V) bool) error {
+	c := t.Cursor()
+	var ok bool
+	if from != nil {
+		// walking starts from the last key which is less or equal than from
+		ok = c.Seek(*from)
+		switch {
+		case c.Err() != nil:
+			return c.Err()
+		case !ok:
+			ok = c.Last()
+		case t.compare(c.Key(), *from) > 0:
+			ok = c.Prev()
+		}
+	} else {
+		ok = c.Last()
+	}
+
+	for ; ok; ok = c.Prev() {
+		k := c.Key()
+		if to != nil && t.compare(k, *to) <= 0 || !fn(k) {
+			break
+		}
+	}
+
+	return c.Err()
+}
+
+// All is a function which returns iterator through all keys of Tree in ascending order.
+// Nodes are read from storage lazily. If storage returns an error, iterator yields it as the last element.
+// Tree isn't locked while body of loop runs, so it can call methods of Tree
+func (t *Tree[V]) All() iter.Seq2[V, error] {
+	return walkSeq(t.Ascend)
+}
+
+// Backward is a function which returns iterator through all keys of Tree in descending order.
+// Nodes are read from storage lazily. If storage returns an error, iterator yields it as the last element.
+// Tree isn't locked while body of loop runs, so it can call methods of Tree
+func (t *Tree[V]) Backward() iter.Seq2[V, error] {
+	return walkSeq(t.Descend)
+}
+
+// Range is a function which returns iterator through keys of Tree in range [lo, hi) in ascending order.
+// Nodes are read from storage lazily. If storage returns an error, iterator yields it as the last element.
+// Tree isn't locked while body of loop runs, so it can call methods of Tree
+func (t *Tree[V]) Range(lo, hi V) iter.Seq2[V, error] {
+	return walkSeq(func(fn func(k V) bool) error {
+		return t.AscendRange(lo, hi, fn)
+	})
+}
+
+// walkSeq - internal function for converting walking function to iterator
+func walkSeq[V any](walk func(fn func(k V) bool) error) iter.Seq2[V, error] {
+	return func(yield func(V, error) bool) {
+		err := walk(func(k V) bool {
+			return yield(k, nil)
+		})
+		if err != nil {
+			var k V
+			yield(k, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package btree
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestTreeStorage_Ascend_Descend(t1 *testing.T) {
+	testFolder := "ascend_descend"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{8, 3, 15, 1, 12, 6, 4, 20, 9, 17, 2, 11}, testFolder)
+
+	type testCase struct {
+		name string
+		walk func(fn func(k int) bool) error
+		want []int
+	}
+	tests := []testCase{
+		{
+			name: "ascend",
+			walk: t.Ascend,
+			want: []int{1, 2, 3, 4, 6, 8, 9, 11, 12, 15, 17, 20},
+		},
+		{
+			name: "descend",
+			walk: t.Descend,
+			want: []int{20, 17, 15, 12, 11, 9, 8, 6, 4, 3, 2, 1},
+		},
+		{
+			name: "ascend_range",
+			walk: func(fn func(k int) bool) error { return t.AscendRange(4, 12, fn) },
+			want: []int{4, 6, 8, 9, 11},
+		},
+		{
+			name: "ascend_range_bounds_not_in_tree",
+			walk: func(fn func(k int) bool) error { return t.AscendRange(5, 16, fn) },
+			want: []int{6, 8, 9, 11, 12, 15},
+		},
+		{
+			name: "descend_range",
+			walk: func(fn func(k int) bool) error { return t.DescendRange(12, 4, fn) },
+			want: []int{12, 11, 9, 8, 6},
+		},
+		{
+			name: "descend_range_bounds_not_in_tree",
+			walk: func(fn func(k int) bool) error { return t.DescendRange(16, 0, fn) },
+			want: []int{15, 12, 11, 9, 8, 6, 4, 3, 2, 1},
+		},
+		{
+			name: "empty_range",
+			walk: func(fn func(k int) bool) error { return t.AscendRange(13, 14, fn) },
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			var got []int
+			err := tt.walk(func(k int) bool {
+				got = append(got, k)
+				return true
+			})
+			if err != nil {
+				t1.Errorf("walk error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("walk got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTreeStorage_Ascend_stop(t1 *testing.T) {
+	testFolder := "ascend_stop"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{5, 1, 9, 3, 7, 2, 8, 4, 6}, testFolder)
+
+	var got []int
+	err := t.Ascend(func(k int) bool {
+		got = append(got, k)
+		return len(got) < 4
+	})
+	if err != nil {
+		t1.Errorf("Ascend() error = %v", err)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("Ascend() got = %v, want %v", got, want)
+	}
+}
+
+func createIntTreeStorage(t int, elements []int, name string) *Tree[int] {
+	s, _ := NewDiskStorage[int](name, t)
+	tree, _ := NewTree[int](t, s)
+	for _, el := range elements {
+		tree.Insert(el)
+	}
+
+	return tree
+}
+
+func TestTreeStorage_All_Backward_Range(t1 *testing.T) {
+	testFolder := "all_backward_range"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{5, 1, 9, 3, 7, 2, 8, 4, 6}, testFolder)
+
+	collect := func(seq func(yield func(int, error) bool)) []int {
+		var got []int
+		for k, err := range seq {
+			if err != nil {
+				t1.Fatalf("iterator error = %v", err)
+			}
+			got = append(got, k)
+		}
+		return got
+	}
+
+	if got, want := collect(t.All()), []int{1, 2, 3, 4, 5, 6, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("All() got = %v, want %v", got, want)
+	}
+	if got, want := collect(t.Backward()), []int{9, 8, 7, 6, 5, 4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("Backward() got = %v, want %v", got, want)
+	}
+	if got, want := collect(t.Range(3, 7)), []int{3, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("Range() got = %v, want %v", got, want)
+	}
+
+	var got []int
+	for k := range t.All() {
+		if k > 3 {
+			break
+		}
+		got = append(got, k)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("All() with break got = %v, want %v", got, want)
+	}
+}
+
+func TestTreeStorage_All_storage_error(t1 *testing.T) {
+	testFolder := "all_storage_error"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{1, 2, 3, 4, 5, 6}, testFolder)
+
+	// remove right child of the root: walk should return keys of left child and then an error
+	os.Remove(testFolder + "/2.json")
+
+	var got []int
+	var gotErr error
+	for k, err := range t.All() {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		got = append(got, k)
+	}
+	if gotErr == nil {
+		t1.Errorf("All() expected storage error")
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("All() got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package btree
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// pagedJournalMagic - is a mark of PagedFileStorage's journal saved in the beginning of file
+var pagedJournalMagic = [8]byte{'B', 'T', 'R', 'E', 'E', 'J', 'N', '1'}
+
+// pagedJournal - internal structure of pages which should be written to PagedFileStorage together.
+// Journal is saved as magic, page size, pages with their numbers, amount of pages and crc32 of all previous bytes
+type pagedJournal struct {
+	pageSize int
+	pages    []uint64
+	data     [][]byte
+}
+
+// add - internal function for adding content of page to journal. Content is filled by zeros to page size
+func (j *pagedJournal) add(page uint64, data []byte) {
+	buf := make([]byte, j.pageSize)
+	copy(buf, data)
+
+	j.pages = append(j.pages, page)
+	j.data = append(j.data, buf)
+}
+
+// encode - internal function for encoding journal to bytes
+func (j *pagedJournal) encode() []byte {
+	buf := make([]byte, 0, 8+4+len(j.pages)*(8+j.pageSize)+8+4)
+	buf = append(buf, pagedJournalMagic[:]...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(j.pageSize))
+	for i, page := range j.pages {
+		buf = binary.LittleEndian.AppendUint64(buf, page)
+		buf = append(buf, j.data[i]...)
+	}
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(j.pages)))
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+}
+
+// decodePagedJournal - internal function for decoding journal.
+// Returns false if journal is incomplete (it was interrupted while writing)
+func decodePagedJournal(data []byte) (*pagedJournal, bool) {
+	if len(data) < 8+4+8+4 || [8]byte(data[:8]) != pagedJournalMagic {
+		return nil, false
+	}
+
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, false
+	}
+
+	j := &pagedJournal{pageSize: int(binary.LittleEndian.Uint32(data[8:]))}
+	count := binary.LittleEndian.Uint64(body[len(body)-8:])
+	pages := body[12 : len(body)-8]
+	if j.pageSize < minPageSize || uint64(len(pages)) != count*uint64(8+j.pageSize) {
+		return nil, false
+	}
+
+	for len(pages) > 0 {
+		j.pages = append(j.pages, binary.LittleEndian.Uint64(pages))
+		j.data = append(j.data, pages[8:8+j.pageSize])
+		pages = pages[8+j.pageSize:]
+	}
+
+	return j, true
+}
@@ -0,0 +1,114 @@
+package btree
+
+import (
+	"errors"
+	"slices"
+)
+
+// KeyStatus is an outcome of key in InsertMany and DeleteMany
+type KeyStatus byte
+
+const (
+	// KeyInserted - key was inserted
+	KeyInserted KeyStatus = iota + 1
+	// KeyPresent - key was already in Tree (or earlier in the same batch), it isn't inserted again
+	KeyPresent
+	// KeyDeleted - key was deleted
+	KeyDeleted
+	// KeyMissing - key wasn't found in Tree (or was deleted earlier in the same batch)
+	KeyMissing
+)
+
+// String - returns name of KeyStatus
+func (s KeyStatus) String() string {
+	switch s {
+	case KeyInserted:
+		return "inserted"
+	case KeyPresent:
+		return "present"
+	case KeyDeleted:
+		return "deleted"
+	case KeyMissing:
+		return "missing"
+	}
+
+	return "unknown"
+}
+
+// InsertMany is a function for inserting batch of keys into Tree.
+// Keys are inserted in ascending order, so neighbour keys go down through the same nodes, and every changed Node
+// is written to storage once with all other changes together. Every key goes down from root once: key which is
+// already in Tree is found on the path of inserting and is skipped.
+// Returns status of every key (KeyInserted or KeyPresent) in order of keys
+// - param keys should be `ordered type` (`int`, `string`, `float` etc.)
+func (t *Tree[V]) InsertMany(keys []V) ([]KeyStatus, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.updateMany(keys, OpInsert, func(t *Tree[V], k V) (KeyStatus, error) {
+		err := t.insert(k, nil)
+		if errors.Is(err, errKeyExists) {
+			return KeyPresent, nil
+		}
+
+		return KeyInserted, err
+	})
+}
+
+// DeleteMany is a function for deleting batch of keys from Tree.
+// Keys are deleted in ascending order, so neighbour keys go down through the same nodes, and every changed Node
+// is written to storage once with all other changes together. Keys which aren't found in Tree are skipped
+// before any Node is changed.
+// Returns status of every key (KeyDeleted or KeyMissing) in order of keys
+// - param keys should be `ordered type` (`int`, `string`, `float` etc.)
+func (t *Tree[V]) DeleteMany(keys []V) ([]KeyStatus, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.updateMany(keys, OpDelete, func(t *Tree[V], k V) (KeyStatus, error) {
+		_, err := t.delete(k)
+		if errors.Is(err, errKeyNotFound) {
+			return KeyMissing, nil
+		}
+
+		return KeyDeleted, err
+	})
+}
+
+// updateMany - internal function: runs operation fn for every key of keys in ascending order over one batch
+// and applies all changed nodes and metadata to storage together. Operation of kind is saved to batch for every key
+// which fn changed (KeyInserted or KeyDeleted). If fn fails, storage and Tree aren't changed
+func (t *Tree[V]) updateMany(keys []V, kind OpKind, fn func(t *Tree[V], k V) (KeyStatus, error)) ([]KeyStatus, error) {
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		return t.compare(keys[i], keys[j])
+	})
+
+	b := newBatchStorage[V](t.storage)
+	view := t.withStorage(b)
+	statuses := make([]KeyStatus, len(keys))
+	for _, i := range order {
+		s, err := fn(view, keys[i])
+		if err != nil {
+			b.discard()
+			return nil, err
+		}
+		statuses[i] = s
+		if s == KeyInserted || s == KeyDeleted {
+			b.ops = append(b.ops, Op[V]{Kind: kind, Key: keys[i]})
+		}
+	}
+
+	if len(b.ops) == 0 {
+		return statuses, nil
+	}
+
+	if err := t.commit(b, view.meta); err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,126 @@
+package btree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTree_InsertMany(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	rs := &testStorage{MemoryStorage: ms}
+	t, _ := NewTree[int](2, rs)
+	t.Insert(5)
+
+	got, err := t.InsertMany([]int{9, 5, 1, 7, 3, 9})
+	if err != nil {
+		t1.Fatalf("InsertMany() error = %v", err)
+	}
+	want := []KeyStatus{KeyInserted, KeyPresent, KeyInserted, KeyInserted, KeyInserted, KeyPresent}
+	if !reflect.DeepEqual(got, want) {
+		t1.Errorf("InsertMany() got = %v, want %v", got, want)
+	}
+	if got := collectKeys(t.All()); !reflect.DeepEqual(got, []int{1, 3, 5, 7, 9}) {
+		t1.Errorf("All() got = %v", got)
+	}
+
+	// all changes are applied by one batch, every Node is written once
+	keys := rand.New(rand.NewSource(1)).Perm(1000)
+	rs.batches = nil
+	if _, err = t.InsertMany(keys); err != nil {
+		t1.Fatalf("InsertMany() error = %v", err)
+	}
+	checkTreeBalance(t, t1)
+	if len(rs.batches) != 1 {
+		t1.Fatalf("amount of applied batches got = %v, want 1", len(rs.batches))
+	}
+	b := rs.batches[0]
+	names := make(map[string]bool)
+	for _, n := range b.Writes {
+		if names[n.Name] {
+			t1.Errorf("Node %s is written twice", n.Name)
+		}
+		names[n.Name] = true
+	}
+	if len(b.Writes) != t.NodeCount() || len(b.Ops) != 995 {
+		t1.Errorf("batch has %v writes and %v ops, want %v and 995", len(b.Writes), len(b.Ops), t.NodeCount())
+	}
+}
+
+func TestTree_DeleteMany(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 3)
+	rs := &testStorage{MemoryStorage: ms}
+	t, _ := NewTree[int](3, rs)
+	t.InsertMany(makeRange(0, 500))
+
+	keys := append(rand.New(rand.NewSource(2)).Perm(250), 1000, 0)
+	rs.batches = nil
+	got, err := t.DeleteMany(keys)
+	if err != nil {
+		t1.Fatalf("DeleteMany() error = %v", err)
+	}
+	for i, k := range keys {
+		want := KeyDeleted
+		if k == 1000 || i == len(keys)-1 {
+			want = KeyMissing
+		}
+		if got[i] != want {
+			t1.Errorf("status of key %v got = %v, want %v", k, got[i], want)
+		}
+	}
+	checkTreeBalance(t, t1)
+	if got := collectKeys(t.All()); !reflect.DeepEqual(got, makeRange(250, 500)) {
+		t1.Errorf("All() got = %v", got)
+	}
+	if len(rs.batches) != 1 || len(rs.batches[0].Ops) != 250 {
+		t1.Errorf("DeleteMany() applied %v batches", len(rs.batches))
+	}
+
+	// nothing is applied if no key is deleted
+	rs.batches = nil
+	if got, _ := t.DeleteMany([]int{1, 2}); !reflect.DeepEqual(got, []KeyStatus{KeyMissing, KeyMissing}) {
+		t1.Errorf("DeleteMany() got = %v", got)
+	}
+	if len(rs.batches) != 0 {
+		t1.Errorf("DeleteMany() of missing keys applied %v batches", len(rs.batches))
+	}
+}
+
+func TestTree_InsertMany_one_descent(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 3)
+	ts := &testStorage{MemoryStorage: ms}
+	t, _ := NewTree[int](3, ts)
+	for i := 0; i < 1000; i++ {
+		t.Insert(i * 2)
+	}
+
+	// new key goes down from root once: presence of key is checked on the path of inserting
+	for k := 1; k < 100; k += 2 {
+		ts.reads = 0
+		if got, _ := t.InsertMany([]int{k, k - 1}); !reflect.DeepEqual(got, []KeyStatus{KeyInserted, KeyPresent}) {
+			t1.Fatalf("InsertMany() got = %v", got)
+		}
+		if ts.reads > 2*t.Height() {
+			t1.Fatalf("InsertMany() of 2 keys read %v nodes, height is %v", ts.reads, t.Height())
+		}
+	}
+	checkTreeBalance(t, t1)
+}
+
+func TestTree_InsertMany_failed(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	fs := &testStorage{MemoryStorage: ms}
+	t, _ := NewTree[int](2, fs)
+	t.InsertMany(makeRange(0, 20))
+
+	// storage and tree aren't changed by failed batch
+	fs.failAfter = fs.reads + 3
+	if _, err := t.InsertMany(makeRange(20, 100)); err == nil {
+		t1.Fatalf("InsertMany() error = nil, want error")
+	}
+	fs.failAfter = 0
+	if t.Len() != 20 || len(ms.nodes) != t.NodeCount() {
+		t1.Errorf("after failed InsertMany() tree has %v keys and storage %v nodes", t.Len(), len(ms.nodes))
+	}
+	checkTreeBalance(t, t1)
+}
@@ -0,0 +1,111 @@
+package btree
+
+import (
+	"encoding/json"
+	"errors"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Map is a B-tree which keeps a value alongside each key.
+// Values are saved in Node's Values encoded to json
+type Map[K, V any] struct {
+	tree *Tree[K]
+}
+
+// NewMap is a function for creation empty Map
+// - type K should be `ordered type` (`int`, `string`, `float` etc.)
+// - type V can be any type which can be encoded to json
+// - param t is a min degree of b-tree. It can't be less than 2
+// - param s is a storage when will be saved map data
+func NewMap[K constraints.Ordered, V any](t int, s NodeStorage[K]) (*Map[K, V], error) {
+	tree, err := NewTree[K](t, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Map[K, V]{tree: tree}, nil
+}
+
+// NewMapFunc is a function for creation empty Map with custom comparator of keys
+// - type K can be any type which can be saved in storage
+// - type V can be any type which can be encoded to json
+// - param t is a min degree of b-tree. It can't be less than 2
+// - param compare returns a negative number when a < b, a positive number when a > b and zero when a == b
+// - param s is a storage when will be saved map data
+func NewMapFunc[K, V any](t int, compare func(a, b K) int, s NodeStorage[K]) (*Map[K, V], error) {
+	tree, err := NewTreeFunc[K](t, compare, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Map[K, V]{tree: tree}, nil
+}
+
+// Get is a function for getting value by key from Map.
+// Returns value and true if key exists in map, else - returns zero value and false
+func (m *Map[K, V]) Get(k K) (V, bool, error) {
+	m.tree.mu.RLock()
+	defer m.tree.mu.RUnlock()
+
+	var v V
+	data, ok, err := m.tree.get(k)
+	if err != nil || !ok {
+		return v, false, err
+	}
+
+	v, err = decodeValue[V](data)
+	if err != nil {
+		return v, false, err
+	}
+
+	return v, true, nil
+}
+
+// Put is a function for saving value by key to Map. If key exists - its value will be replaced
+func (m *Map[K, V]) Put(k K, v V) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	m.tree.mu.Lock()
+	defer m.tree.mu.Unlock()
+
+	return m.tree.update(Op[K]{Kind: OpPut, Key: k, Value: data}, func(t *Tree[K]) error {
+		_, _, err := t.put(k, data)
+		return err
+	})
+}
+
+// Delete is a function for deleting key from Map. Returns deleted value
+// if Map doesn't have this key - function returns an error
+func (m *Map[K, V]) Delete(k K) (V, error) {
+	m.tree.mu.Lock()
+	defer m.tree.mu.Unlock()
+
+	var data []byte
+	err := m.tree.update(Op[K]{Kind: OpDelete, Key: k}, func(t *Tree[K]) error {
+		var err error
+		data, err = t.delete(k)
+		return err
+	})
+	if err != nil {
+		var v V
+		return v, err
+	}
+
+	return decodeValue[V](data)
+}
+
+// decodeValue - internal function for decoding value saved in Node
+func decodeValue[V any](data []byte) (V, error) {
+	var v V
+	if data == nil {
+		return v, errors.New("value is not saved in Node")
+	}
+
+	err := json.Unmarshal(data, &v)
+
+	return v, err
+}
@@ -0,0 +1,124 @@
+package btree
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestMap_Put_Get(t1 *testing.T) {
+	testFolder := "map_put_get"
+	defer os.RemoveAll(testFolder)
+	m := createMapStorage(3, testFolder)
+
+	for i := 0; i < 10; i++ {
+		if err := m.Put(i, i*10); err != nil {
+			t1.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	// replace value of existing key
+	if err := m.Put(4, 400); err != nil {
+		t1.Fatalf("Put() error = %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		want := i * 10
+		if i == 4 {
+			want = 400
+		}
+		got, ok, err := m.Get(i)
+		if err != nil || !ok || got != want {
+			t1.Errorf("Get(%d) got = %v, %v, %v, want %v", i, got, ok, err, want)
+		}
+	}
+
+	if _, ok, err := m.Get(100); ok || err != nil {
+		t1.Errorf("Get(100) got = %v, %v, want not found", ok, err)
+	}
+}
+
+func TestMap_Delete(t1 *testing.T) {
+	testFolder := "map_delete"
+	defer os.RemoveAll(testFolder)
+	m := createMapStorage(3, testFolder)
+
+	for i := 0; i < 6; i++ {
+		m.Put(i, i*10)
+	}
+
+	// root has key 3 and two leaves: key's value should be moved from the leaf together with key
+	for _, k := range []int{3, 2, 0} {
+		got, err := m.Delete(k)
+		if err != nil || got != k*10 {
+			t1.Errorf("Delete(%d) got = %v, %v, want %v", k, got, err, k*10)
+		}
+	}
+
+	for _, k := range []int{1, 4, 5} {
+		got, ok, err := m.Get(k)
+		if err != nil || !ok || got != k*10 {
+			t1.Errorf("Get(%d) got = %v, %v, %v, want %v", k, got, ok, err, k*10)
+		}
+	}
+
+	if _, err := m.Delete(3); err == nil {
+		t1.Errorf("Delete(3) expected error for deleted key")
+	}
+}
+
+func TestMap_Delete_deep_tree(t1 *testing.T) {
+	testFolder := "map_delete_deep_tree"
+	defer os.RemoveAll(testFolder)
+	m := createMapStorage(2, testFolder)
+
+	keys := rand.New(rand.NewSource(1)).Perm(100)
+	for _, k := range keys {
+		m.Put(k, k*10)
+	}
+
+	// values should be moved together with keys while nodes are merged and keys are borrowed
+	for _, k := range keys[:50] {
+		got, err := m.Delete(k)
+		if err != nil || got != k*10 {
+			t1.Fatalf("Delete(%d) got = %v, %v, want %v", k, got, err, k*10)
+		}
+	}
+
+	for _, k := range keys[50:] {
+		got, ok, err := m.Get(k)
+		if err != nil || !ok || got != k*10 {
+			t1.Errorf("Get(%d) got = %v, %v, %v, want %v", k, got, ok, err, k*10)
+		}
+	}
+}
+
+func TestMap_Delete_merge_nodes_without_values(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	m, _ := NewMap[int, int](2, ms)
+
+	// keys of set are saved without values: root [2] and left leaf [1] don't have values, right leaf [4] has
+	for _, k := range []int{1, 2, 3} {
+		m.tree.Insert(k)
+	}
+	m.Put(4, 40)
+	m.tree.Delete(3)
+
+	// leaves and key of root are merged to one Node
+	if err := m.tree.Delete(1); err != nil {
+		t1.Fatalf("Delete(1) error = %v", err)
+	}
+	if got, ok, err := m.Get(4); err != nil || !ok || got != 40 {
+		t1.Errorf("Get(4) got = %v, %v, %v, want 40", got, ok, err)
+	}
+	if _, _, err := m.Get(2); err == nil {
+		t1.Errorf("Get(2) error = nil, want error for key without value")
+	}
+}
+
+func createMapStorage(t int, name string) *Map[int, int] {
+	s, _ := NewDiskStorage[int](name, t)
+	m, _ := NewMap[int, int](t, s)
+
+	return m
+}
@@ -0,0 +1,174 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"strconv"
+	"sync"
+)
+
+// MemoryStorage - is a storage for keeping nodes of Tree in memory.
+// Nodes are copied on reading and writing (with values and keys of slice kind, e.g. []byte), so changes of read
+// or written Node don't change the storage
+// - param name is a name of storage
+type MemoryStorage[V any] struct {
+	name     string
+	manifest Manifest
+
+	mu     sync.RWMutex
+	nodes  map[string]*Node[V]
+	meta   Meta
+	refs   map[string]int
+	lastID int // the last allocated Node's id. Names of nodes are string ids
+}
+
+// NewMemoryStorage - function for creating of MemoryStorage
+// - param name is a name of storage
+// - param t is a min degree of b-tree. It can't be less than 2
+func NewMemoryStorage[V any](name string, t int) (*MemoryStorage[V], error) {
+	if t < 2 {
+		return nil, errors.New("t can't be less than 2")
+	}
+
+	s := &MemoryStorage[V]{
+		name:     name,
+		manifest: newManifest[V](t),
+		nodes:    make(map[string]*Node[V]),
+		meta:     emptyMeta(),
+		refs:     make(map[string]int),
+	}
+
+	if err := s.Write(NewNode[V](t, RootName)); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Name - this function returns name of MemoryStorage
+func (ms *MemoryStorage[V]) Name() string {
+	return ms.name
+}
+
+// AllocName - function for allocating name for new Node.
+// Names are increasing ids, so a name is never reused
+func (ms *MemoryStorage[V]) AllocName() (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.lastID++
+
+	return strconv.Itoa(ms.lastID), nil
+}
+
+// Read - function for reading copy of Node by name from MemoryStorage
+// - param name - is name of Node
+func (ms *MemoryStorage[V]) Read(name string) (*Node[V], error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	n, ok := ms.nodes[name]
+	if !ok {
+		return nil, fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+
+	return n.clone(), nil
+}
+
+// Write - function for writing copy of Node to MemoryStorage
+func (ms *MemoryStorage[V]) Write(n *Node[V]) error {
+	c := n.clone()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.nodes[c.Name] = c
+
+	return nil
+}
+
+// Delete - function for deleting Node from MemoryStorage
+// param name - is name of Node
+func (ms *MemoryStorage[V]) Delete(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.nodes[name]; !ok {
+		return fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+	delete(ms.nodes, name)
+
+	return nil
+}
+
+// Apply - function for applying Batch to MemoryStorage. Readers see all changes of Batch or none of them
+func (ms *MemoryStorage[V]) Apply(b Batch[V]) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, n := range b.Writes {
+		ms.nodes[n.Name] = n.clone()
+	}
+	for name, c := range b.Refs {
+		if c == 1 {
+			delete(ms.refs, name)
+		} else {
+			ms.refs[name] = c
+		}
+	}
+	for _, name := range b.Deletes {
+		delete(ms.nodes, name)
+		delete(ms.refs, name)
+	}
+	if b.Meta != nil {
+		ms.meta = *b.Meta
+	}
+
+	return nil
+}
+
+// ReadRefs - function for reading amounts of references to shared nodes from MemoryStorage
+func (ms *MemoryStorage[V]) ReadRefs() (map[string]int, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return maps.Clone(ms.refs), nil
+}
+
+// ReadMeta - function for reading Tree's metadata from MemoryStorage
+func (ms *MemoryStorage[V]) ReadMeta() (Meta, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return ms.meta, nil
+}
+
+// WriteMeta - function for writing Tree's metadata to MemoryStorage
+func (ms *MemoryStorage[V]) WriteMeta(m Meta) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.meta = m
+
+	return nil
+}
+
+// Manifest - function for reading parameters of Tree kept in MemoryStorage
+func (ms *MemoryStorage[V]) Manifest() (Manifest, error) {
+	return ms.manifest, nil
+}
+
+// nodeNames - internal function: returns names of all nodes kept in MemoryStorage
+func (ms *MemoryStorage[V]) nodeNames() ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	names := make([]string, 0, len(ms.nodes))
+	for name := range ms.nodes {
+		names = append(names, name)
+	}
+
+	return names, nil
+}
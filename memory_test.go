@@ -0,0 +1,109 @@
+package btree
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryStorage_copies_nodes(t1 *testing.T) {
+	s, err := NewMemoryStorage[string]("memory", 3)
+	if err != nil {
+		t1.Fatalf("NewMemoryStorage() error = %v", err)
+	}
+
+	n := &Node[string]{
+		Name:   "1",
+		Keys:   []string{"A", "B"},
+		Values: [][]byte{[]byte("a"), []byte("b")},
+		Leaf:   true,
+	}
+	s.Write(n)
+
+	// changes of written Node don't change the storage
+	n.Keys[0] = "C"
+	n.Values[1][0] = 'c'
+
+	got, err := s.Read("1")
+	if err != nil {
+		t1.Fatalf("Read() error = %v", err)
+	}
+	want := &Node[string]{
+		Name:   "1",
+		Keys:   []string{"A", "B"},
+		Values: [][]byte{[]byte("a"), []byte("b")},
+		Leaf:   true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t1.Errorf("Read() got = %+v, want %+v", got, want)
+	}
+
+	// changes of read Node don't change the storage
+	got.Keys = append(got.Keys[:1], "D")
+	if again, _ := s.Read("1"); !reflect.DeepEqual(again, want) {
+		t1.Errorf("Read() got = %+v, want %+v", again, want)
+	}
+
+	if err := s.Delete("1"); err != nil {
+		t1.Errorf("Delete() error = %v", err)
+	}
+	if _, err := s.Read("1"); err == nil {
+		t1.Errorf("Read() of deleted Node expected error")
+	}
+}
+
+func TestMemoryStorage_copies_slice_keys(t1 *testing.T) {
+	s, _ := NewMemoryStorage[[]byte]("memory", 2)
+	t, _ := NewTreeFunc[[]byte](2, bytes.Compare, s)
+	for _, k := range []string{"a", "k", "x"} {
+		t.Insert([]byte(k))
+	}
+
+	// changes of inserted key don't change the tree
+	buf := []byte("m")
+	t.Insert(buf)
+	buf[0] = 'z'
+	if ok, err := t.Exists([]byte("m")); !ok || err != nil {
+		t1.Errorf("Exists(m) got = %v, %v, want true", ok, err)
+	}
+
+	// changes of read key don't change the tree
+	k, _, _ := t.Max()
+	k[0] = 'b'
+	if k, _, _ = t.Max(); string(k) != "x" {
+		t1.Errorf("Max() got = %s, want x", k)
+	}
+	if report, err := t.Verify(); err != nil || !report.OK() {
+		t1.Errorf("Verify() got = %v, %v, want no violations", report, err)
+	}
+}
+
+func TestMemoryStorage_Tree(t1 *testing.T) {
+	s, _ := NewMemoryStorage[int]("memory", 2)
+	t, err := NewTree[int](2, s)
+	if err != nil {
+		t1.Fatalf("NewTree() error = %v", err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, k := range r.Perm(500) {
+		t.Insert(k)
+	}
+	for _, k := range r.Perm(500)[:250] {
+		if err := t.Delete(k); err != nil {
+			t1.Fatalf("Delete(%d) error = %v", k, err)
+		}
+	}
+	checkTreeBalance(t, t1)
+
+	// metadata is kept in storage
+	t2, _ := NewTree[int](2, s)
+	if t2.Len() != 250 || t2.Height() != t.Height() || t2.NodeCount() != t.NodeCount() {
+		t1.Errorf("Meta got = %v, %v, %v", t2.Len(), t2.Height(), t2.NodeCount())
+	}
+
+	if _, err := NewTree[int](3, s); err == nil {
+		t1.Errorf("NewTree() with another t expected error")
+	}
+}
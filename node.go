@@ -1,21 +1,29 @@
 package btree
 
-import "golang.org/x/exp/constraints"
+import (
+	"bytes"
+	"reflect"
+	"slices"
+)
 
 // Node is the structure of Tree's Node.
 // Name is a name of Tree's node
 // Keys is an array of ordered keys (each key has ordered type)
+// Values is an array of encoded values (Values[i] belongs to Keys[i]). It's empty for trees without values
 // Children is an array of Node names (children of this Node)
+// Counts is an array of key amounts in children's subtrees (Counts[i] belongs to Children[i])
 // Leaf is a sign: Node is leaf or not
-type Node[V constraints.Ordered] struct {
+type Node[V any] struct {
 	Name     string
 	Keys     []V
+	Values   [][]byte `json:",omitempty"`
 	Children []string
+	Counts   []int `json:",omitempty"`
 	Leaf     bool
 }
 
 // NewNode - internal function for creating empty Node
-func NewNode[V constraints.Ordered](t int, name string) *Node[V] {
+func NewNode[V any](t int, name string) *Node[V] {
 	return &Node[V]{
 		Name:     name,
 		Keys:     make([]V, 0, 2*t-1),
@@ -24,50 +32,143 @@ func NewNode[V constraints.Ordered](t int, name string) *Node[V] {
 	}
 }
 
-// insertKey - insert key to Node on the i-position in key's array
-func (n *Node[V]) insertKey(i int, k V) {
+// clone - returns a copy of Node which doesn't share slices with n. Keys of slice kind (e.g. []byte) are copied too
+func (n *Node[V]) clone() *Node[V] {
+	c := &Node[V]{
+		Name:     n.Name,
+		Keys:     cloneKeys(n.Keys),
+		Children: slices.Clone(n.Children),
+		Counts:   slices.Clone(n.Counts),
+		Leaf:     n.Leaf,
+	}
+
+	if n.Values != nil {
+		c.Values = make([][]byte, len(n.Values))
+		for i, v := range n.Values {
+			c.Values[i] = bytes.Clone(v)
+		}
+	}
+
+	return c
+}
+
+// cloneKeys - internal function: returns a copy of keys. Keys of slice kind are copied, so they don't share
+// their elements with keys (other keys are copied by value)
+func cloneKeys[V any](keys []V) []V {
+	c := slices.Clone(keys)
+	if reflect.TypeFor[V]().Kind() != reflect.Slice {
+		return c
+	}
+
+	for i := range c {
+		if b, ok := any(&c[i]).(*[]byte); ok {
+			*b = bytes.Clone(*b)
+			continue
+		}
+		if k := reflect.ValueOf(&c[i]).Elem(); !k.IsNil() {
+			copied := reflect.MakeSlice(k.Type(), k.Len(), k.Len())
+			reflect.Copy(copied, k)
+			k.Set(copied)
+		}
+	}
+
+	return c
+}
+
+// size - returns amount of keys in subtree of Node
+func (n *Node[V]) size() int {
+	size := len(n.Keys)
+	for _, c := range n.Counts {
+		size += c
+	}
+
+	return size
+}
+
+// value - returns value of key on the i-position (nil if Node doesn't keep values)
+func (n *Node[V]) value(i int) []byte {
+	if n.Values == nil {
+		return nil
+	}
+
+	return n.Values[i]
+}
+
+// insertKey - insert key with its value to Node on the i-position in key's array
+// value can be nil if tree doesn't keep values
+func (n *Node[V]) insertKey(i int, k V, v []byte) {
 	n.Keys = append(n.Keys, k)
 	copy(n.Keys[i+1:], n.Keys[i:])
 	n.Keys[i] = k
+
+	if v == nil && n.Values == nil {
+		return
+	}
+	if n.Values == nil {
+		n.Values = make([][]byte, len(n.Keys)-1)
+	}
+	n.Values = append(n.Values, v)
+	copy(n.Values[i+1:], n.Values[i:])
+	n.Values[i] = v
 }
 
-// insertChild - insert child to children of Node on the i-position
-func (n *Node[V]) insertChild(i int, child string) {
+// setKey - replace key and its value on the i-position in key's array
+func (n *Node[V]) setKey(i int, k V, v []byte) {
+	n.Keys[i] = k
+	if n.Values != nil {
+		n.Values[i] = v
+	}
+}
+
+// insertChild - insert child with amount of keys in its subtree to children of Node on the i-position
+func (n *Node[V]) insertChild(i int, child string, count int) {
 	n.Children = append(n.Children, child)
 	copy(n.Children[i+1:], n.Children[i:])
 	n.Children[i] = child
+
+	n.Counts = append(n.Counts, count)
+	copy(n.Counts[i+1:], n.Counts[i:])
+	n.Counts[i] = count
 }
 
 // newSplitNode - internal function. Create additional Node from nodeToSplit and returns it
-func newSplitNode[V constraints.Ordered](t int, nodeToSplit *Node[V], name string) *Node[V] {
+func newSplitNode[V any](t int, nodeToSplit *Node[V], name string) *Node[V] {
 	n := NewNode[V](t, name)
 	n.Leaf = nodeToSplit.Leaf
 	n.Keys = append(n.Keys, nodeToSplit.Keys[t:]...)
+	if nodeToSplit.Values != nil {
+		n.Values = append(make([][]byte, 0, 2*t-1), nodeToSplit.Values[t:]...)
+	}
 
 	if !nodeToSplit.Leaf {
 		n.Children = append(n.Children, nodeToSplit.Children[t:]...)
+		n.Counts = append(make([]int, 0, 2*t), nodeToSplit.Counts[t:]...)
 	}
 
 	return n
 }
 
-// deleteMaxKey - delete max key in array of Node's keys
-func (n *Node[V]) deleteMaxKey() V {
-	maxKey := n.Keys[len(n.Keys)-1]
-	n.deleteKeyByIndex(len(n.Keys) - 1)
+// deleteMaxKey - delete max key in array of Node's keys. Returns deleted key and its value
+func (n *Node[V]) deleteMaxKey() (V, []byte) {
+	i := len(n.Keys) - 1
+	maxKey, maxValue := n.Keys[i], n.value(i)
+	n.deleteKeyByIndex(i)
 
-	return maxKey
+	return maxKey, maxValue
 }
 
-// deleteMinKey - delete min key in array of Node's keys
-func (n *Node[V]) deleteMinKey() V {
-	minKey := n.Keys[0]
+// deleteMinKey - delete min key in array of Node's keys. Returns deleted key and its value
+func (n *Node[V]) deleteMinKey() (V, []byte) {
+	minKey, minValue := n.Keys[0], n.value(0)
 	n.deleteKeyByIndex(0)
 
-	return minKey
+	return minKey, minValue
 }
 
-// deleteKeyByIndex - delete key by key in array of Node's keys
+// deleteKeyByIndex - delete key (and its value) by index in array of Node's keys
 func (n *Node[V]) deleteKeyByIndex(i int) {
 	n.Keys = append(n.Keys[:i], n.Keys[i+1:]...)
+	if n.Values != nil {
+		n.Values = append(n.Values[:i], n.Values[i+1:]...)
+	}
 }
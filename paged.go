@@ -0,0 +1,698 @@
+package btree
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultPageSize - is a size of page in PagedFileStorage if other size isn't set
+	DefaultPageSize = 4096
+	// minPageSize - is a min size of page in PagedFileStorage: header should fit in one page
+	minPageSize = 256
+
+	// headerPage - is a number of page with header of PagedFileStorage
+	headerPage = 0
+	// rootPage - is a number of page with root Node. Other nodes are named by numbers of their pages
+	rootPage = 1
+
+	// journalExt - is an extension of PagedFileStorage's journal file
+	journalExt = ".journal"
+)
+
+// pagedMagic - is a mark of PagedFileStorage's file saved in the beginning of header
+var pagedMagic = [8]byte{'B', 'T', 'R', 'E', 'E', 'P', 'G', '1'}
+
+// types of pages. Page which was allocated, but wasn't written yet has type pageEmpty
+const (
+	pageEmpty byte = iota
+	pageNode
+	pageFree
+)
+
+const (
+	// pageNodeHeaderSize - size of Node page's header: type and length of encoded Node
+	pageNodeHeaderSize = 1 + 4
+	// pageFreeHeaderSize - size of free page's header: type and number of the next free page
+	pageFreeHeaderSize = 1 + 8
+	// pagedHeaderSize - size of fixed part of header page: magic, page size, amount of pages,
+	// the first free page, metadata and length of manifest
+	pagedHeaderSize = 8 + 4 + 8 + 8 + 3*8 + 4
+)
+
+// PagedOptions is the structure of PagedFileStorage's parameters.
+// PageSize is a size of every page in file (DefaultPageSize if it's zero). Every Node should fit in one page
+// Codec is an encoding of nodes (JSONCodec if it's nil)
+type PagedOptions[V any] struct {
+	PageSize int
+	Codec    Codec[V]
+}
+
+// PagedFileStorage - is a storage for keeping nodes of Tree in one file of fixed-size pages.
+// Page 0 is a header with metadata and manifest, page 1 keeps root, other nodes are named by numbers of their pages.
+// Pages of deleted nodes are kept in a free list and are reused by AllocName.
+// Batch is applied atomically through journal file which is kept near the file of storage
+// - param fileName is a name of file where will be saved tree
+type PagedFileStorage[V any] struct {
+	fileName string
+	file     *os.File
+	codec    Codec[V]
+	pageSize int
+
+	mu        sync.Mutex
+	pageCount uint64          // amount of pages in file including header
+	freeHead  uint64          // number of the first free page, 0 if there are no free pages
+	allocated bool            // pages were allocated after header was written, so pageCount and freeHead aren't saved yet
+	unwritten map[uint64]bool // pages allocated by AllocName which nodes weren't written to yet
+	meta      Meta
+	manifest  Manifest
+}
+
+// NewPagedFileStorage - function for creating of PagedFileStorage with default page size and json codec
+// - param fileName is name of file where will be saved tree. File shouldn't exist
+// - param t is a min degree of b-tree. It can't be less than 2
+func NewPagedFileStorage[V any](fileName string, t int) (*PagedFileStorage[V], error) {
+	return NewPagedFileStorageWithOptions[V](fileName, t, PagedOptions[V]{})
+}
+
+// NewPagedFileStorageWithOptions - function for creating of PagedFileStorage
+// - param fileName is name of file where will be saved tree. File shouldn't exist
+// - param t is a min degree of b-tree. It can't be less than 2
+// - param opts is a page size and codec of storage
+func NewPagedFileStorageWithOptions[V any](fileName string, t int, opts PagedOptions[V]) (*PagedFileStorage[V], error) {
+	if t < 2 {
+		return nil, errors.New("t can't be less than 2")
+	}
+
+	if opts.PageSize == 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.PageSize < minPageSize {
+		return nil, fmt.Errorf("page size can't be less than %d", minPageSize)
+	}
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec[V]{}
+	}
+
+	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PagedFileStorage[V]{
+		fileName:  fileName,
+		file:      file,
+		codec:     opts.Codec,
+		pageSize:  opts.PageSize,
+		pageCount: rootPage + 1,
+		meta:      emptyMeta(),
+		manifest:  newManifest[V](t),
+	}
+	s.manifest.Codec = opts.Codec.Name()
+
+	if err = s.writeHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if err = s.Write(NewNode[V](t, RootName)); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// OpenPagedFileStorage - function for opening of PagedFileStorage with a tree which was saved earlier.
+// Nodes are decoded by built-in codec which name is saved in manifest
+// - param fileName is name of file where tree is saved
+// - param t is a min degree of b-tree. It should be the same as t of saved tree
+func OpenPagedFileStorage[V any](fileName string, t int) (*PagedFileStorage[V], error) {
+	return OpenPagedFileStorageWithCodec[V](fileName, t, nil)
+}
+
+// OpenPagedFileStorageWithCodec - function for opening of PagedFileStorage with a tree which was saved earlier
+// - param fileName is name of file where tree is saved
+// - param t is a min degree of b-tree. It should be the same as t of saved tree
+// - param codec is an encoding of nodes. Its name should be the same as name saved in manifest.
+// If codec is nil, built-in codec is chosen by saved name
+func OpenPagedFileStorageWithCodec[V any](fileName string, t int, codec Codec[V]) (*PagedFileStorage[V], error) {
+	file, err := os.OpenFile(fileName, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PagedFileStorage[V]{
+		fileName: fileName,
+		file:     file,
+	}
+
+	if err = s.open(t, codec); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("tree in %s: %w", fileName, err)
+	}
+
+	return s, nil
+}
+
+// open - internal function: reads header of opened file and checks it
+func (ps *PagedFileStorage[V]) open(t int, codec Codec[V]) error {
+	if err := ps.recover(); err != nil {
+		return fmt.Errorf("can't recover journal: %w", err)
+	}
+
+	if err := ps.readHeader(); err != nil {
+		return err
+	}
+
+	if err := checkManifest[V](ps.manifest, t); err != nil {
+		return err
+	}
+
+	if codec == nil {
+		var err error
+		if codec, err = codecByName[V](ps.manifest.Codec); err != nil {
+			return err
+		}
+	}
+	if ps.manifest.Codec != codec.Name() {
+		return fmt.Errorf("tree is saved by codec %s, but %s was requested", ps.manifest.Codec, codec.Name())
+	}
+	ps.codec = codec
+
+	return nil
+}
+
+// Name - this function returns name of file where PagedFileStorage keeps a Tree
+func (ps *PagedFileStorage[V]) Name() string {
+	return ps.fileName
+}
+
+// Close - function for closing file of PagedFileStorage. Storage can't be used after closing
+func (ps *PagedFileStorage[V]) Close() error {
+	return ps.file.Close()
+}
+
+// Sync - function for saving written pages of PagedFileStorage to disk
+func (ps *PagedFileStorage[V]) Sync() error {
+	return ps.file.Sync()
+}
+
+// AllocName - function for allocating name for new Node.
+// Name is a number of page: the first page from free list or a new page in the end of file.
+// Allocation is saved in header by the next Apply (in the same journal with written nodes) or Write,
+// so after a crash between AllocName and Apply the page is free again. If Node isn't written to allocated page,
+// the page is returned to free list by Delete (e.g. when changes are discarded)
+func (ps *PagedFileStorage[V]) AllocName() (string, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	page := ps.freeHead
+	if page != 0 {
+		buf, err := ps.readPage(page)
+		if err != nil {
+			return "", err
+		}
+		if buf[0] != pageFree {
+			return "", fmt.Errorf("page %d from free list isn't free", page)
+		}
+		ps.freeHead = binary.LittleEndian.Uint64(buf[1:])
+	} else {
+		page = ps.pageCount
+		ps.pageCount++
+	}
+
+	ps.allocated = true
+	if ps.unwritten == nil {
+		ps.unwritten = make(map[uint64]bool)
+	}
+	ps.unwritten[page] = true
+
+	// page from free list stays free until Node is written to it, new page stays empty
+	if page == ps.pageCount-1 {
+		if err := ps.writePage(page, []byte{pageEmpty}); err != nil {
+			return "", err
+		}
+	}
+
+	return pageName(page), nil
+}
+
+// reserveName - internal function for allocating page of Node with name again when log of WALStorage is replayed.
+// Page is removed from free list or file is extended to it. Allocation is saved at once
+func (ps *PagedFileStorage[V]) reserveName(name string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	page, err := strconv.ParseUint(name, 10, 64)
+	if err != nil || page <= rootPage {
+		return fmt.Errorf("name %s can't be allocated", name)
+	}
+
+	if page >= ps.pageCount {
+		ps.pageCount = page + 1
+		if err = ps.writePage(page, []byte{pageEmpty}); err != nil {
+			return err
+		}
+		return ps.writeHeader()
+	}
+
+	prev := uint64(0)
+	for p, i := ps.freeHead, uint64(0); p != 0 && i < ps.pageCount; i++ {
+		buf, err := ps.readPage(p)
+		if err != nil {
+			return err
+		}
+		if buf[0] != pageFree {
+			return fmt.Errorf("page %d from free list isn't free", p)
+		}
+		next := binary.LittleEndian.Uint64(buf[1:])
+		if p != page {
+			prev, p = p, next
+			continue
+		}
+
+		// page is unlinked from free list first: after a crash page can be lost, but it's never used twice
+		if prev != 0 {
+			err = ps.writePage(prev, freePage(next))
+		} else {
+			ps.freeHead = next
+			err = ps.writeHeader()
+		}
+		if err != nil {
+			return err
+		}
+		return ps.writePage(page, []byte{pageEmpty})
+	}
+
+	// page isn't free: it was allocated before
+	return nil
+}
+
+// Read - function for reading Node by name from PagedFileStorage
+// - param name - is name of Node
+func (ps *PagedFileStorage[V]) Read(name string) (*Node[V], error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	page, err := ps.pageNumber(name)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := ps.readPage(page)
+	if err != nil {
+		return nil, err
+	}
+	if buf[0] != pageNode {
+		return nil, fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+
+	l := int(binary.LittleEndian.Uint32(buf[1:]))
+	if l > ps.pageSize-pageNodeHeaderSize {
+		return nil, fmt.Errorf("page %d is broken: length of Node is %d", page, l)
+	}
+
+	return ps.codec.Unmarshal(buf[pageNodeHeaderSize : pageNodeHeaderSize+l])
+}
+
+// Write - function for writing Node to its page in PagedFileStorage.
+// Returns an error if encoded Node doesn't fit in one page
+func (ps *PagedFileStorage[V]) Write(n *Node[V]) error {
+	data, err := ps.encode(n)
+	if err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	page, err := ps.pageNumber(n.Name)
+	if err != nil {
+		return err
+	}
+
+	// allocation of page is saved before Node: after a crash page can be lost, but it's never used twice
+	if ps.allocated {
+		if err = ps.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	if err = ps.writePage(page, nodePage(data)); err != nil {
+		return err
+	}
+	delete(ps.unwritten, page)
+
+	return nil
+}
+
+// Delete - function for deleting Node from PagedFileStorage. Page of Node (or allocated page without Node)
+// is added to free list
+// param name - is name of Node
+func (ps *PagedFileStorage[V]) Delete(name string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	page, err := ps.pageNumber(name)
+	if err != nil {
+		return err
+	}
+	if page == rootPage {
+		return errors.New("root can't be deleted")
+	}
+
+	buf, err := ps.readPage(page)
+	if err != nil {
+		return err
+	}
+	if buf[0] == pageFree && !ps.unwritten[page] {
+		return fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+
+	if err = ps.writePage(page, freePage(ps.freeHead)); err != nil {
+		return err
+	}
+	ps.freeHead = page
+	delete(ps.unwritten, page)
+
+	return ps.writeHeader()
+}
+
+// Apply - function for applying Batch to PagedFileStorage atomically.
+// All changed pages including header are saved to journal file and synced, then they are written to their places.
+// OpenPagedFileStorage writes pages from complete journal again if a crash happens
+func (ps *PagedFileStorage[V]) Apply(b Batch[V]) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	j, err := ps.commit(b)
+	if err != nil {
+		return err
+	}
+
+	return ps.writeJournal(j)
+}
+
+// commit - internal function for saving all pages changed by Batch to journal
+func (ps *PagedFileStorage[V]) commit(b Batch[V]) (*pagedJournal, error) {
+	j := &pagedJournal{pageSize: ps.pageSize}
+	var pages []uint64 // written and deleted pages
+	for _, n := range b.Writes {
+		page, err := ps.pageNumber(n.Name)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+		data, err := ps.encode(n)
+		if err != nil {
+			return nil, err
+		}
+		j.add(page, nodePage(data))
+	}
+
+	freeHead := ps.freeHead
+	for _, name := range b.Deletes {
+		page, err := ps.pageNumber(name)
+		if err != nil {
+			return nil, err
+		}
+		if page == rootPage {
+			return nil, errors.New("root can't be deleted")
+		}
+
+		buf, err := ps.readPage(page)
+		if err != nil {
+			return nil, err
+		}
+		if buf[0] == pageFree && !ps.unwritten[page] {
+			continue
+		}
+		j.add(page, freePage(freeHead))
+		freeHead = page
+		pages = append(pages, page)
+	}
+
+	meta := ps.meta
+	if b.Meta != nil {
+		meta = *b.Meta
+	}
+	header, err := ps.header(freeHead, meta)
+	if err != nil {
+		return nil, err
+	}
+	j.add(headerPage, header)
+
+	if err = writeSyncedFile(ps.journalName(), j.encode()); err != nil {
+		return nil, err
+	}
+	if err = syncFolder(filepath.Dir(ps.fileName)); err != nil {
+		return nil, err
+	}
+	ps.freeHead, ps.meta = freeHead, meta
+	ps.allocated = false
+	for _, page := range pages {
+		delete(ps.unwritten, page)
+	}
+
+	return j, nil
+}
+
+// recover - internal function: writes pages from complete journal. Incomplete journal isn't applied
+func (ps *PagedFileStorage[V]) recover() error {
+	data, err := os.ReadFile(ps.journalName())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if j, ok := decodePagedJournal(data); ok {
+		ps.pageSize = j.pageSize
+		return ps.writeJournal(j)
+	}
+
+	return os.Remove(ps.journalName())
+}
+
+// writeJournal - internal function for writing pages of journal to file of storage and removing journal
+func (ps *PagedFileStorage[V]) writeJournal(j *pagedJournal) error {
+	for i, page := range j.pages {
+		if err := ps.writePage(page, j.data[i]); err != nil {
+			return err
+		}
+	}
+	if err := ps.file.Sync(); err != nil {
+		return err
+	}
+
+	return os.Remove(ps.journalName())
+}
+
+// journalName - internal function: returns name of journal file of storage
+func (ps *PagedFileStorage[V]) journalName() string {
+	return ps.fileName + journalExt
+}
+
+// ReadMeta - function for reading Tree's metadata from header of PagedFileStorage
+func (ps *PagedFileStorage[V]) ReadMeta() (Meta, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.meta, nil
+}
+
+// WriteMeta - function for writing Tree's metadata to header of PagedFileStorage
+func (ps *PagedFileStorage[V]) WriteMeta(m Meta) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.meta = m
+
+	return ps.writeHeader()
+}
+
+// Manifest - function for reading parameters of Tree saved in header of PagedFileStorage
+func (ps *PagedFileStorage[V]) Manifest() (Manifest, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.manifest, nil
+}
+
+// PageCount - returns amount of pages in file of PagedFileStorage including header and free pages
+func (ps *PagedFileStorage[V]) PageCount() int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return int(ps.pageCount)
+}
+
+// nodeNames - internal function: returns names of all nodes saved in pages of PagedFileStorage
+func (ps *PagedFileStorage[V]) nodeNames() ([]string, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	var names []string
+	typ := make([]byte, 1)
+	for page := uint64(rootPage); page < ps.pageCount; page++ {
+		if _, err := ps.file.ReadAt(typ, int64(page)*int64(ps.pageSize)); err != nil {
+			return nil, fmt.Errorf("can't read page %d: %w", page, err)
+		}
+		if typ[0] == pageNode {
+			names = append(names, pageName(page))
+		}
+	}
+
+	return names, nil
+}
+
+// encode - internal function for encoding Node. Returns an error if encoded Node doesn't fit in one page
+func (ps *PagedFileStorage[V]) encode(n *Node[V]) ([]byte, error) {
+	data, err := ps.codec.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > ps.pageSize-pageNodeHeaderSize {
+		return nil, fmt.Errorf("Node %s needs %d bytes, but page size is %d", n.Name, len(data)+pageNodeHeaderSize, ps.pageSize)
+	}
+
+	return data, nil
+}
+
+// readHeader - internal function for reading header page
+func (ps *PagedFileStorage[V]) readHeader() error {
+	buf := make([]byte, pagedHeaderSize)
+	if _, err := ps.file.ReadAt(buf, 0); err != nil {
+		return fmt.Errorf("can't read header: %w", err)
+	}
+	if [8]byte(buf[:8]) != pagedMagic {
+		return errors.New("file isn't a paged tree storage")
+	}
+
+	ps.pageSize = int(binary.LittleEndian.Uint32(buf[8:]))
+	ps.pageCount = binary.LittleEndian.Uint64(buf[12:])
+	ps.freeHead = binary.LittleEndian.Uint64(buf[20:])
+	ps.meta = Meta{
+		Len:       int(binary.LittleEndian.Uint64(buf[28:])),
+		Height:    int(binary.LittleEndian.Uint64(buf[36:])),
+		NodeCount: int(binary.LittleEndian.Uint64(buf[44:])),
+	}
+	l := int(binary.LittleEndian.Uint32(buf[52:]))
+	if ps.pageSize < minPageSize || l > ps.pageSize-pagedHeaderSize || ps.pageCount <= rootPage {
+		return errors.New("header is broken")
+	}
+
+	data := make([]byte, l)
+	if _, err := ps.file.ReadAt(data, pagedHeaderSize); err != nil {
+		return fmt.Errorf("can't read manifest: %w", err)
+	}
+
+	return json.Unmarshal(data, &ps.manifest)
+}
+
+// writeHeader - internal function for writing header page
+func (ps *PagedFileStorage[V]) writeHeader() error {
+	buf, err := ps.header(ps.freeHead, ps.meta)
+	if err != nil {
+		return err
+	}
+	if err = ps.writePage(headerPage, buf); err != nil {
+		return err
+	}
+	ps.allocated = false
+
+	return nil
+}
+
+// header - internal function: returns content of header page with the first free page freeHead and metadata meta
+func (ps *PagedFileStorage[V]) header(freeHead uint64, meta Meta) ([]byte, error) {
+	data, err := json.Marshal(ps.manifest)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > ps.pageSize-pagedHeaderSize {
+		return nil, fmt.Errorf("manifest needs %d bytes, but page size is %d", len(data)+pagedHeaderSize, ps.pageSize)
+	}
+
+	buf := make([]byte, pagedHeaderSize, pagedHeaderSize+len(data))
+	copy(buf, pagedMagic[:])
+	binary.LittleEndian.PutUint32(buf[8:], uint32(ps.pageSize))
+	binary.LittleEndian.PutUint64(buf[12:], ps.pageCount)
+	binary.LittleEndian.PutUint64(buf[20:], freeHead)
+	binary.LittleEndian.PutUint64(buf[28:], uint64(meta.Len))
+	binary.LittleEndian.PutUint64(buf[36:], uint64(meta.Height))
+	binary.LittleEndian.PutUint64(buf[44:], uint64(meta.NodeCount))
+	binary.LittleEndian.PutUint32(buf[52:], uint32(len(data)))
+
+	return append(buf, data...), nil
+}
+
+// readPage - internal function for reading whole page by its number
+func (ps *PagedFileStorage[V]) readPage(page uint64) ([]byte, error) {
+	buf := make([]byte, ps.pageSize)
+	if _, err := ps.file.ReadAt(buf, int64(page)*int64(ps.pageSize)); err != nil {
+		return nil, fmt.Errorf("can't read page %d: %w", page, err)
+	}
+
+	return buf, nil
+}
+
+// writePage - internal function for writing data to page. The rest of page is filled by zeros
+func (ps *PagedFileStorage[V]) writePage(page uint64, data []byte) error {
+	buf := make([]byte, ps.pageSize)
+	copy(buf, data)
+
+	_, err := ps.file.WriteAt(buf, int64(page)*int64(ps.pageSize))
+
+	return err
+}
+
+// pageNumber - internal function: returns number of page where Node with name is kept
+func (ps *PagedFileStorage[V]) pageNumber(name string) (uint64, error) {
+	if name == RootName {
+		return rootPage, nil
+	}
+
+	page, err := strconv.ParseUint(name, 10, 64)
+	if err != nil || page <= rootPage || page >= ps.pageCount {
+		return 0, fmt.Errorf("not found Node with name %s: %w", name, fs.ErrNotExist)
+	}
+
+	return page, nil
+}
+
+// nodePage - internal function: returns content of page with encoded Node
+func nodePage(data []byte) []byte {
+	buf := make([]byte, pageNodeHeaderSize, pageNodeHeaderSize+len(data))
+	buf[0] = pageNode
+	binary.LittleEndian.PutUint32(buf[1:], uint32(len(data)))
+
+	return append(buf, data...)
+}
+
+// freePage - internal function: returns content of free page which refers to the next free page
+func freePage(next uint64) []byte {
+	buf := make([]byte, pageFreeHeaderSize)
+	buf[0] = pageFree
+	binary.LittleEndian.PutUint64(buf[1:], next)
+
+	return buf
+}
+
+// pageName - internal function: returns name of Node which is kept in page
+func pageName(page uint64) string {
+	if page == rootPage {
+		return RootName
+	}
+
+	return strconv.FormatUint(page, 10)
+}
@@ -0,0 +1,279 @@
+package btree
+
+import (
+	"errors"
+	"io/fs"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPagedFileStorage_Tree(t1 *testing.T) {
+	fileName := "paged_tree.db"
+	defer os.Remove(fileName)
+
+	s, err := NewPagedFileStorageWithOptions[int](fileName, 2, PagedOptions[int]{PageSize: 512, Codec: BinaryCodec[int]{}})
+	if err != nil {
+		t1.Fatalf("NewPagedFileStorageWithOptions() error = %v", err)
+	}
+	t, _ := NewTree[int](2, s)
+
+	// file grows only when there are no free pages, so it has as many pages as the max amount of nodes
+	maxNodes := 0
+	r := rand.New(rand.NewSource(1))
+	for _, k := range r.Perm(500) {
+		t.Insert(k)
+		maxNodes = max(maxNodes, t.NodeCount())
+	}
+	for _, k := range r.Perm(500)[:400] {
+		if err := t.Delete(k); err != nil {
+			t1.Fatalf("Delete(%d) error = %v", k, err)
+		}
+	}
+	checkTreeBalance(t, t1)
+
+	for k := 500; k < 900; k++ {
+		t.Insert(k)
+		maxNodes = max(maxNodes, t.NodeCount())
+	}
+	if got := s.PageCount(); got != maxNodes+1 {
+		t1.Errorf("PageCount() got = %v, want %v", got, maxNodes+1)
+	}
+
+	info, _ := os.Stat(fileName)
+	if info.Size() != int64(s.PageCount()*512) {
+		t1.Errorf("file size got = %v, want %v", info.Size(), s.PageCount()*512)
+	}
+	s.Close()
+
+	// page size, codec and metadata are read from header
+	s2, err := OpenPagedFileStorage[int](fileName, 2)
+	if err != nil {
+		t1.Fatalf("OpenPagedFileStorage() error = %v", err)
+	}
+	defer s2.Close()
+
+	t2, err := NewTree[int](2, s2)
+	if err != nil {
+		t1.Fatalf("NewTree() error = %v", err)
+	}
+	if t2.Len() != t.Len() || t2.Height() != t.Height() || t2.NodeCount() != t.NodeCount() {
+		t1.Errorf("Meta got = %v, %v, %v", t2.Len(), t2.Height(), t2.NodeCount())
+	}
+	checkTreeBalance(t2, t1)
+}
+
+func TestPagedFileStorage_Delete(t1 *testing.T) {
+	fileName := "paged_delete.db"
+	defer os.Remove(fileName)
+
+	s, _ := NewPagedFileStorage[string](fileName, 3)
+	defer s.Close()
+
+	name, _ := s.AllocName()
+	if name != "2" {
+		t1.Errorf("AllocName() got = %v, want 2", name)
+	}
+	s.Write(&Node[string]{Name: name, Keys: []string{"A"}, Leaf: true})
+
+	if err := s.Delete(name); err != nil {
+		t1.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Read(name); !errors.Is(err, fs.ErrNotExist) {
+		t1.Errorf("Read() of deleted Node error = %v, want fs.ErrNotExist", err)
+	}
+	if err := s.Delete(name); err == nil {
+		t1.Errorf("Delete() of deleted Node expected error")
+	}
+	if err := s.Delete(RootName); err == nil {
+		t1.Errorf("Delete() of root expected error")
+	}
+
+	// free page is allocated again
+	if again, _ := s.AllocName(); again != name {
+		t1.Errorf("AllocName() got = %v, want %v", again, name)
+	}
+	if _, err := s.Read("100"); !errors.Is(err, fs.ErrNotExist) {
+		t1.Errorf("Read() of not allocated page error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestPagedFileStorage_AllocName_crash(t1 *testing.T) {
+	fileName := "paged_alloc_crash.db"
+	defer os.Remove(fileName)
+
+	s, _ := NewPagedFileStorage[int](fileName, 2)
+	t, _ := NewTree[int](2, s)
+	for i := 0; i < 50; i++ {
+		t.Insert(i)
+	}
+	for i := 0; i < 20; i++ {
+		t.Delete(i)
+	}
+	pages := s.PageCount()
+
+	// crash between AllocName and Apply: allocated pages (from free list and new ones) aren't lost
+	var names []string
+	for i := 0; i < 10; i++ {
+		name, _ := s.AllocName()
+		names = append(names, name)
+	}
+	s.Close()
+
+	s, err := OpenPagedFileStorage[int](fileName, 2)
+	if err != nil {
+		t1.Fatalf("OpenPagedFileStorage() error = %v", err)
+	}
+	defer s.Close()
+	if s.PageCount() != pages {
+		t1.Errorf("PageCount() got = %v, want %v", s.PageCount(), pages)
+	}
+	for _, want := range names {
+		if got, _ := s.AllocName(); got != want {
+			t1.Errorf("AllocName() got = %v, want %v", got, want)
+		}
+	}
+
+	t, _ = NewTree[int](2, s)
+	checkTreeBalance(t, t1)
+}
+
+func TestPagedFileStorage_rollback(t1 *testing.T) {
+	fileName := "paged_rollback.db"
+	defer os.Remove(fileName)
+
+	s, _ := NewPagedFileStorage[int](fileName, 2)
+	defer s.Close()
+	t, _ := NewTree[int](2, s)
+	for i := 0; i < 100; i++ {
+		t.Insert(i)
+	}
+
+	// pages allocated by discarded changes are returned to free list and are allocated again
+	insert := func(tx *Txn[int], from int) {
+		for k := from; k < from+60; k++ {
+			if err := tx.Insert(k); err != nil {
+				t1.Fatalf("Insert(%d) in transaction error = %v", k, err)
+			}
+		}
+	}
+	tx := t.Begin()
+	insert(tx, 1000)
+	tx.Rollback()
+	pages := s.PageCount()
+	for i := 0; i < 20; i++ {
+		tx = t.Begin()
+		insert(tx, 1000)
+		tx.Rollback()
+
+		tx = t.Begin()
+		insert(tx, 2000)
+		t.Delete(i)
+		if err := tx.Commit(); !errors.Is(err, ErrTxnConflict) {
+			t1.Fatalf("Commit() error = %v, want ErrTxnConflict", err)
+		}
+	}
+	if s.PageCount() != pages {
+		t1.Errorf("PageCount() after rollbacks got = %v, want %v", s.PageCount(), pages)
+	}
+
+	tx = t.Begin()
+	insert(tx, 1000)
+	if err := tx.Commit(); err != nil {
+		t1.Fatalf("Commit() error = %v", err)
+	}
+	if report, err := t.Verify(); err != nil || !report.OK() {
+		t1.Errorf("Verify() got = %v, %v, want no violations", report, err)
+	}
+}
+
+func TestPagedFileStorage_errors(t1 *testing.T) {
+	fileName := "paged_errors.db"
+	defer os.Remove(fileName)
+
+	if _, err := NewPagedFileStorageWithOptions[string](fileName, 2, PagedOptions[string]{PageSize: 100}); err == nil {
+		t1.Errorf("NewPagedFileStorageWithOptions() with small page expected error")
+	}
+
+	s, _ := NewPagedFileStorageWithOptions[string](fileName, 2, PagedOptions[string]{PageSize: minPageSize})
+	err := s.Write(&Node[string]{Name: RootName, Keys: []string{strings.Repeat("A", minPageSize)}, Leaf: true})
+	if err == nil {
+		t1.Errorf("Write() of Node bigger than page expected error")
+	}
+	s.Close()
+
+	if _, err := NewPagedFileStorage[string](fileName, 2); err == nil {
+		t1.Errorf("NewPagedFileStorage() of existing file expected error")
+	}
+	if _, err := OpenPagedFileStorage[string](fileName, 3); err == nil {
+		t1.Errorf("OpenPagedFileStorage() with another t expected error")
+	}
+	if _, err := OpenPagedFileStorage[int](fileName, 2); err == nil {
+		t1.Errorf("OpenPagedFileStorage() with another key type expected error")
+	}
+	if _, err := OpenPagedFileStorageWithCodec[string](fileName, 2, GobCodec[string]{}); err == nil {
+		t1.Errorf("OpenPagedFileStorageWithCodec() with another codec expected error")
+	}
+	if _, err := OpenPagedFileStorage[string]("go.mod", 2); err == nil {
+		t1.Errorf("OpenPagedFileStorage() of not paged file expected error")
+	}
+}
+
+func TestPagedFileStorage_journal(t1 *testing.T) {
+	fileName := "paged_journal.db"
+	defer os.Remove(fileName)
+	defer os.Remove(fileName + journalExt)
+
+	s, _ := NewPagedFileStorage[int](fileName, 2)
+	t, _ := NewTree[int](2, s)
+	for i := 0; i < 30; i++ {
+		t.Insert(i)
+	}
+
+	// crash after journal was saved: pages are written again on opening
+	b := collectBatch(t, func(t *Tree[int]) error {
+		_, err := t.delete(10)
+		return err
+	})
+	if _, err := s.commit(b); err != nil {
+		t1.Fatalf("commit() error = %v", err)
+	}
+	s.Close()
+
+	s, err := OpenPagedFileStorage[int](fileName, 2)
+	if err != nil {
+		t1.Fatalf("OpenPagedFileStorage() error = %v", err)
+	}
+	t, _ = NewTree[int](2, s)
+	if ok, _ := t.Exists(10); ok || t.Len() != 29 {
+		t1.Errorf("Exists(10), Len() got = %v, %v, want false, 29", ok, t.Len())
+	}
+	checkTreeBalance(t, t1)
+
+	// crash while journal was written: incomplete journal is discarded
+	b = collectBatch(t, func(t *Tree[int]) error {
+		_, err := t.delete(20)
+		return err
+	})
+	if _, err := s.commit(b); err != nil {
+		t1.Fatalf("commit() error = %v", err)
+	}
+	s.Close()
+	info, _ := os.Stat(fileName + journalExt)
+	os.Truncate(fileName+journalExt, info.Size()-10)
+
+	s, err = OpenPagedFileStorage[int](fileName, 2)
+	if err != nil {
+		t1.Fatalf("OpenPagedFileStorage() error = %v", err)
+	}
+	defer s.Close()
+	t, _ = NewTree[int](2, s)
+	if ok, _ := t.Exists(20); !ok || t.Len() != 29 {
+		t1.Errorf("Exists(20), Len() got = %v, %v, want true, 29", ok, t.Len())
+	}
+	checkTreeBalance(t, t1)
+	if _, err := os.Stat(fileName + journalExt); !os.IsNotExist(err) {
+		t1.Errorf("journal wasn't removed: %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package btree
+
+// Min is a function for getting min key of Tree. If Tree is empty - returns false
+func (t *Tree[V]) Min() (V, bool, error) {
+	return t.edge(false)
+}
+
+// Max is a function for getting max key of Tree. If Tree is empty - returns false
+func (t *Tree[V]) Max() (V, bool, error) {
+	return t.edge(true)
+}
+
+// Floor is a function for getting the largest key which is less or equal than k.
+// If there is no such key - returns false
+func (t *Tree[V]) Floor(k V) (V, bool, error) {
+	return t.lower(k, true)
+}
+
+// Ceiling is a function for getting the smallest key which is greater or equal than k.
+// If there is no such key - returns false
+func (t *Tree[V]) Ceiling(k V) (V, bool, error) {
+	return t.upper(k, true)
+}
+
+// Predecessor is a function for getting the largest key which is less than k.
+// If there is no such key - returns false
+func (t *Tree[V]) Predecessor(k V) (V, bool, error) {
+	return t.lower(k, false)
+}
+
+// Successor is a function for getting the smallest key which is greater than k.
+// If there is no such key - returns false
+func (t *Tree[V]) Successor(k V) (V, bool, error) {
+	return t.upper(k, false)
+}
+
+// edge - internal function for getting min (or max if param right is true) key of Tree
+func (t *Tree[V]) edge(right bool) (V, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var k V
+	n, err := t.storage.Read(t.root)
+	if err != nil {
+		return k, false, err
+	}
+
+	for !n.Leaf {
+		i := 0
+		if right {
+			i = len(n.Children) - 1
+		}
+		if n, err = t.storage.Read(n.Children[i]); err != nil {
+			return k, false, err
+		}
+	}
+
+	if len(n.Keys) == 0 {
+		return k, false, nil
+	}
+
+	if right {
+		return n.Keys[len(n.Keys)-1], true, nil
+	}
+
+	return n.Keys[0], true, nil
+}
+
+// lower - internal function for getting the largest key which is less than k (or equal if inclusive)
+func (t *Tree[V]) lower(k V, inclusive bool) (V, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var result V
+	found := false
+
+	n, err := t.storage.Read(t.root)
+	if err != nil {
+		return result, false, err
+	}
+
+	for {
+		// i is an amount of keys in Node which are less than k (or equal if inclusive)
+		i := 0
+		for i < len(n.Keys) && t.before(n.Keys[i], k, inclusive) {
+			i++
+		}
+
+		if i > 0 {
+			result, found = n.Keys[i-1], true
+			if t.compare(n.Keys[i-1], k) == 0 {
+				return result, true, nil
+			}
+		}
+
+		if n.Leaf {
+			return result, found, nil
+		}
+
+		if n, err = t.storage.Read(n.Children[i]); err != nil {
+			return result, false, err
+		}
+	}
+}
+
+// before - internal function: returns true if a is less than k (or equal if inclusive)
+func (t *Tree[V]) before(a, k V, inclusive bool) bool {
+	c := t.compare(a, k)
+
+	return c < 0 || inclusive && c == 0
+}
+
+// upper - internal function for getting the smallest key which is greater than k (or equal if inclusive)
+func (t *Tree[V]) upper(k V, inclusive bool) (V, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var result V
+	found := false
+
+	n, err := t.storage.Read(t.root)
+	if err != nil {
+		return result, false, err
+	}
+
+	for {
+		// i is an index of the first key in Node which is greater than k (or equal if inclusive)
+		i := 0
+		for i < len(n.Keys) && t.before(n.Keys[i], k, !inclusive) {
+			i++
+		}
+
+		if i < len(n.Keys) {
+			result, found = n.Keys[i], true
+			if t.compare(n.Keys[i], k) == 0 {
+				return result, true, nil
+			}
+		}
+
+		if n.Leaf {
+			return result, found, nil
+		}
+
+		if n, err = t.storage.Read(n.Children[i]); err != nil {
+			return result, false, err
+		}
+	}
+}
+
+// Rank is a function for getting amount of keys in Tree which are less than k
+func (t *Tree[V]) Rank(k V) (int, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n, err := t.storage.Read(t.root)
+	if err != nil {
+		return 0, err
+	}
+
+	rank := 0
+	for {
+		i := 0
+		for i < len(n.Keys) && t.compare(n.Keys[i], k) < 0 {
+			if !n.Leaf {
+				rank += n.Counts[i]
+			}
+			rank++
+			i++
+		}
+
+		if n.Leaf {
+			return rank, nil
+		}
+
+		if i < len(n.Keys) && t.compare(n.Keys[i], k) == 0 {
+			return rank + n.Counts[i], nil
+		}
+
+		if n, err = t.storage.Read(n.Children[i]); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Select is a function for getting the i-th smallest key of Tree (i starts from 0).
+// If i is out of range - returns false
+func (t *Tree[V]) Select(i int) (V, bool, error) {
+	var k V
+	if i < 0 {
+		return k, false, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n, err := t.storage.Read(t.root)
+	if err != nil {
+		return k, false, err
+	}
+
+	for !n.Leaf {
+		j := 0
+		for j < len(n.Keys) && i >= n.Counts[j] {
+			i -= n.Counts[j]
+			if i == 0 {
+				return n.Keys[j], true, nil
+			}
+			i--
+			j++
+		}
+
+		if n, err = t.storage.Read(n.Children[j]); err != nil {
+			return k, false, err
+		}
+	}
+
+	if i >= len(n.Keys) {
+		return k, false, nil
+	}
+
+	return n.Keys[i], true, nil
+}
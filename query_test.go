@@ -0,0 +1,101 @@
+package btree
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTreeStorage_Queries(t1 *testing.T) {
+	testFolder := "queries"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{80, 30, 150, 10, 120, 60, 40, 200, 90, 170, 20, 110}, testFolder)
+
+	type testCase struct {
+		name   string
+		query  func(k int) (int, bool, error)
+		k      int
+		want   int
+		wantOk bool
+	}
+	tests := []testCase{
+		{name: "floor_existing_key", query: t.Floor, k: 90, want: 90, wantOk: true},
+		{name: "floor_between_keys", query: t.Floor, k: 100, want: 90, wantOk: true},
+		{name: "floor_key_from_root", query: t.Floor, k: 85, want: 80, wantOk: true},
+		{name: "floor_before_min", query: t.Floor, k: 5, want: 0, wantOk: false},
+		{name: "ceiling_existing_key", query: t.Ceiling, k: 150, want: 150, wantOk: true},
+		{name: "ceiling_between_keys", query: t.Ceiling, k: 65, want: 80, wantOk: true},
+		{name: "ceiling_after_max", query: t.Ceiling, k: 201, want: 0, wantOk: false},
+		{name: "predecessor_existing_key", query: t.Predecessor, k: 90, want: 80, wantOk: true},
+		{name: "predecessor_of_min", query: t.Predecessor, k: 10, want: 0, wantOk: false},
+		{name: "predecessor_between_keys", query: t.Predecessor, k: 155, want: 150, wantOk: true},
+		{name: "successor_existing_key", query: t.Successor, k: 80, want: 90, wantOk: true},
+		{name: "successor_of_max", query: t.Successor, k: 200, want: 0, wantOk: false},
+		{name: "successor_between_keys", query: t.Successor, k: 145, want: 150, wantOk: true},
+		{name: "min", query: func(int) (int, bool, error) { return t.Min() }, want: 10, wantOk: true},
+		{name: "max", query: func(int) (int, bool, error) { return t.Max() }, want: 200, wantOk: true},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			got, ok, err := tt.query(tt.k)
+			if err != nil {
+				t1.Errorf("query error = %v", err)
+			}
+			if got != tt.want || ok != tt.wantOk {
+				t1.Errorf("query(%d) got = %v, %v, want %v, %v", tt.k, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestTreeStorage_Queries_empty_tree(t1 *testing.T) {
+	testFolder := "queries_empty_tree"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(3, []int{}, testFolder)
+
+	if _, ok, err := t.Min(); ok || err != nil {
+		t1.Errorf("Min() got = %v, %v, want not found", ok, err)
+	}
+	if _, ok, err := t.Max(); ok || err != nil {
+		t1.Errorf("Max() got = %v, %v, want not found", ok, err)
+	}
+	if _, ok, err := t.Floor(1); ok || err != nil {
+		t1.Errorf("Floor() got = %v, %v, want not found", ok, err)
+	}
+}
+
+func TestTreeStorage_Rank_Select(t1 *testing.T) {
+	testFolder := "rank_select"
+	defer os.RemoveAll(testFolder)
+	keys := []int{80, 30, 150, 10, 120, 60, 40, 200, 90, 170, 20, 110}
+	t := createIntTreeStorage(3, keys, testFolder)
+
+	// delete keys from leaf and from root: counts of subtrees should be changed
+	t.Delete(40)
+	t.Delete(150)
+	sorted := []int{10, 20, 30, 60, 80, 90, 110, 120, 170, 200}
+
+	for i, k := range sorted {
+		rank, err := t.Rank(k)
+		if err != nil || rank != i {
+			t1.Errorf("Rank(%d) got = %v, %v, want %v", k, rank, err, i)
+		}
+
+		got, ok, err := t.Select(i)
+		if err != nil || !ok || got != k {
+			t1.Errorf("Select(%d) got = %v, %v, %v, want %v", i, got, ok, err, k)
+		}
+	}
+
+	if rank, _ := t.Rank(100); rank != 6 {
+		t1.Errorf("Rank(100) got = %v, want 6", rank)
+	}
+	if rank, _ := t.Rank(500); rank != len(sorted) {
+		t1.Errorf("Rank(500) got = %v, want %v", rank, len(sorted))
+	}
+	if _, ok, _ := t.Select(len(sorted)); ok {
+		t1.Errorf("Select(%d) expected out of range", len(sorted))
+	}
+	if _, ok, _ := t.Select(-1); ok {
+		t1.Errorf("Select(-1) expected out of range")
+	}
+}
@@ -0,0 +1,240 @@
+package btree
+
+import (
+	"errors"
+	"iter"
+	"sync/atomic"
+)
+
+// ErrSnapshotReleased - error of using snapshot after Release
+var ErrSnapshotReleased = errors.New("snapshot is released")
+
+// errReadOnly - error of changing read-only storage of snapshot
+var errReadOnly = errors.New("snapshot is read-only")
+
+// Snapshot is a read-only view of Tree at the moment when it was taken.
+// Snapshot shares nodes with Tree like a clone, but its root is kept in memory and it doesn't need RefStorage.
+// While snapshot exists, Tree copies nodes which snapshot can see instead of changing them,
+// old versions are kept in storage until the last snapshot which can see them is released or Tree is opened again
+type Snapshot[V any] struct {
+	tree     *Tree[V] // read-only Tree which reads root taken with snapshot
+	origin   *Tree[V]
+	released atomic.Bool
+}
+
+// snapshotStorage - internal read-only storage of snapshot. Root is kept in memory, other nodes are read from base
+type snapshotStorage[V any] struct {
+	base NodeStorage[V]
+	root *Node[V]
+}
+
+// Snapshot is a function for taking a read-only view of Tree. Snapshot should be released when it isn't needed
+func (t *Tree[V]) Snapshot() (*Snapshot[V], error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	root, err := t.storage.Read(t.root)
+	if err != nil {
+		return nil, err
+	}
+
+	t.refs.mu.Lock()
+	defer t.refs.mu.Unlock()
+
+	for _, name := range root.Children {
+		t.refs.pins[name]++
+	}
+
+	return &Snapshot[V]{
+		tree:   t.withStorage(&snapshotStorage[V]{base: t.storage, root: root}),
+		origin: t,
+	}, nil
+}
+
+// Release is a function for releasing snapshot. Old versions of nodes which only this snapshot could see are deleted
+func (s *Snapshot[V]) Release() error {
+	if s.released.Swap(true) {
+		return nil
+	}
+
+	t := s.origin
+	t.refs.mu.Lock()
+	defer t.refs.mu.Unlock()
+
+	changed := make(map[string]int)
+	var free []string
+	for _, name := range s.tree.storage.(*snapshotStorage[V]).root.Children {
+		if t.refs.pins[name]--; t.refs.pins[name] == 0 {
+			delete(t.refs.pins, name)
+			if t.refs.count(changed, name) == 0 {
+				free = append(free, name)
+			}
+		}
+	}
+
+	deletes, err := freeNodes(t.storage, t.refs, changed, free)
+	if err != nil {
+		return err
+	}
+
+	return t.applyRefs(Batch[V]{Deletes: deletes, Refs: changed})
+}
+
+// reclaim - internal function for deleting old versions of nodes which were kept for snapshots when Tree was opened
+// last time, but weren't deleted by Release (e.g. the process was stopped). Snapshots live only in memory, so after
+// opening nobody can see these nodes. Storage which saves references keeps such nodes with zero amount of references.
+// In other storage (if it can list its nodes) all nodes which aren't a part of Tree are deleted:
+// such storage keeps only one Tree, because it can't be cloned
+func (t *Tree[V]) reclaim() error {
+	t.refs.mu.Lock()
+	defer t.refs.mu.Unlock()
+
+	if t.refs.saved {
+		changed := make(map[string]int)
+		var free []string
+		for name, c := range t.refs.counts {
+			if c == 0 {
+				free = append(free, name)
+			}
+		}
+
+		deletes, err := freeNodes(t.storage, t.refs, changed, free)
+		if err != nil {
+			return err
+		}
+
+		return t.applyRefs(Batch[V]{Deletes: deletes, Refs: changed})
+	}
+
+	nl, ok := t.storage.(nodeLister)
+	if !ok {
+		return nil
+	}
+	names, err := nl.nodeNames()
+	if errors.Is(err, errors.ErrUnsupported) || err == nil && len(names) <= t.meta.NodeCount {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	reachable := make(map[string]bool, t.meta.NodeCount)
+	if err = subtreeNames(t.storage, reachable, []string{t.root}); err != nil {
+		return err
+	}
+	var deletes []string
+	for _, name := range names {
+		if !reachable[name] {
+			deletes = append(deletes, name)
+		}
+	}
+	if len(deletes) == 0 {
+		return nil
+	}
+
+	return applyBatch(t.storage, Batch[V]{Deletes: deletes})
+}
+
+// Exists is a function for searching key in snapshot
+func (s *Snapshot[V]) Exists(k V) (bool, error) {
+	if s.released.Load() {
+		return false, ErrSnapshotReleased
+	}
+
+	return s.tree.Exists(k)
+}
+
+// Len is a function which returns amount of keys in snapshot
+func (s *Snapshot[V]) Len() (int, error) {
+	if s.released.Load() {
+		return 0, ErrSnapshotReleased
+	}
+
+	return s.tree.Len(), nil
+}
+
+// Ascend is a function for walking through all keys of snapshot in ascending order.
+// Walking stops when fn returns false
+func (s *Snapshot[V]) Ascend(fn func(k V) bool) error {
+	if s.released.Load() {
+		return ErrSnapshotReleased
+	}
+
+	return s.tree.Ascend(fn)
+}
+
+// Descend is a function for walking through all keys of snapshot in descending order.
+// Walking stops when fn returns false
+func (s *Snapshot[V]) Descend(fn func(k V) bool) error {
+	if s.released.Load() {
+		return ErrSnapshotReleased
+	}
+
+	return s.tree.Descend(fn)
+}
+
+// AscendRange is a function for walking through keys of snapshot in range [from, to) in ascending order.
+// Walking stops when fn returns false
+func (s *Snapshot[V]) AscendRange(from, to V, fn func(k V) bool) error {
+	if s.released.Load() {
+		return ErrSnapshotReleased
+	}
+
+	return s.tree.AscendRange(from, to, fn)
+}
+
+// DescendRange is a function for walking through keys of snapshot in range (to, from] in descending order.
+// Walking stops when fn returns false
+func (s *Snapshot[V]) DescendRange(from, to V, fn func(k V) bool) error {
+	if s.released.Load() {
+		return ErrSnapshotReleased
+	}
+
+	return s.tree.DescendRange(from, to, fn)
+}
+
+// All is a function which returns iterator through all keys of snapshot in ascending order
+func (s *Snapshot[V]) All() iter.Seq2[V, error] {
+	return walkSeq(s.Ascend)
+}
+
+// Backward is a function which returns iterator through all keys of snapshot in descending order
+func (s *Snapshot[V]) Backward() iter.Seq2[V, error] {
+	return walkSeq(s.Descend)
+}
+
+// Range is a function which returns iterator through keys of snapshot in range [lo, hi) in ascending order
+func (s *Snapshot[V]) Range(lo, hi V) iter.Seq2[V, error] {
+	return walkSeq(func(fn func(k V) bool) error {
+		return s.AscendRange(lo, hi, fn)
+	})
+}
+
+// Name - returns name of base storage
+func (ss *snapshotStorage[V]) Name() string {
+	return ss.base.Name()
+}
+
+// AllocName - returns an error: snapshot can't be changed
+func (ss *snapshotStorage[V]) AllocName() (string, error) {
+	return "", errReadOnly
+}
+
+// Read - returns copy of root taken with snapshot or Node from base storage
+func (ss *snapshotStorage[V]) Read(name string) (*Node[V], error) {
+	if name == ss.root.Name {
+		return ss.root.clone(), nil
+	}
+
+	return ss.base.Read(name)
+}
+
+// Write - returns an error: snapshot can't be changed
+func (ss *snapshotStorage[V]) Write(*Node[V]) error {
+	return errReadOnly
+}
+
+// Delete - returns an error: snapshot can't be changed
+func (ss *snapshotStorage[V]) Delete(string) error {
+	return errReadOnly
+}
@@ -0,0 +1,194 @@
+package btree
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	for i := 0; i < 100; i++ {
+		t.Insert(i)
+	}
+
+	s1, err := t.Snapshot()
+	if err != nil {
+		t1.Fatalf("Snapshot() error = %v", err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, k := range r.Perm(100)[:50] {
+		t.Delete(k)
+	}
+	s2, _ := t.Snapshot()
+	for i := 100; i < 200; i++ {
+		t.Insert(i)
+	}
+	checkTreeBalance(t, t1)
+
+	// snapshots see tree as it was when they were taken
+	if got := collectKeys(s1.All()); !reflect.DeepEqual(got, makeRange(0, 100)) {
+		t1.Errorf("All() of the first snapshot got = %v", got)
+	}
+	l1, _ := s1.Len()
+	l2, _ := s2.Len()
+	if l1 != 100 || l2 != 50 || t.Len() != 150 {
+		t1.Errorf("Len() got = %v, %v, %v, want 100, 50, 150", l1, l2, t.Len())
+	}
+	if got := collectKeys(s2.All()); len(got) != 50 || got[len(got)-1] >= 100 {
+		t1.Errorf("All() of the second snapshot got = %v", got)
+	}
+	if ok, _ := s2.Exists(150); ok {
+		t1.Errorf("Exists(150) in the second snapshot got = true, want false")
+	}
+
+	// old versions of nodes are deleted when the last snapshot which can see them is released
+	s1.Release()
+	if got := collectKeys(s2.All()); len(got) != 50 {
+		t1.Errorf("All() of the second snapshot after release of the first got %v keys, want 50", len(got))
+	}
+	s2.Release()
+	if len(ms.nodes) != t.NodeCount() {
+		t1.Errorf("amount of nodes in storage got = %v, want %v", len(ms.nodes), t.NodeCount())
+	}
+	if _, err := s1.Exists(1); !errors.Is(err, ErrSnapshotReleased) {
+		t1.Errorf("Exists() after Release() error = %v, want ErrSnapshotReleased", err)
+	}
+	if _, err := s1.Len(); !errors.Is(err, ErrSnapshotReleased) {
+		t1.Errorf("Len() after Release() error = %v, want ErrSnapshotReleased", err)
+	}
+
+	// without snapshots nodes are changed in place
+	t.Insert(1000)
+	if len(ms.nodes) != t.NodeCount() {
+		t1.Errorf("amount of nodes in storage got = %v, want %v", len(ms.nodes), t.NodeCount())
+	}
+	checkTreeBalance(t, t1)
+}
+
+func TestSnapshot_Map_value(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	m, _ := NewMap[int, string](2, ms)
+	for i := 0; i < 50; i++ {
+		m.Put(i, "old")
+	}
+
+	s, _ := m.tree.Snapshot()
+	defer s.Release()
+
+	// replacing value changes only Node with key: its ancestors are copied too
+	m.Put(0, "new")
+	data, ok, err := s.tree.get(0)
+	if v, _ := decodeValue[string](data); !ok || err != nil || v != "old" {
+		t1.Errorf("get(0) in snapshot got = %v, %v, %v, want old", v, ok, err)
+	}
+	if v, _, _ := m.Get(0); v != "new" {
+		t1.Errorf("Get(0) got = %v, want new", v)
+	}
+}
+
+func TestSnapshot_concurrent(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	for i := 0; i < 100; i++ {
+		t.Insert(i * 2)
+	}
+
+	s, _ := t.Snapshot()
+	defer s.Release()
+	want := collectKeys(s.All())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			t.Insert(i*2 + 1)
+			t.Delete(i * 2)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if got := collectKeys(s.All()); !reflect.DeepEqual(got, want) {
+				t1.Errorf("All() of snapshot got %v keys while tree was changed, want %v", len(got), len(want))
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSnapshot_not_released(t1 *testing.T) {
+	fileName := "snapshot_not_released.db"
+	defer os.Remove(fileName)
+
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	ps, _ := NewPagedFileStorage[int](fileName, 2)
+	for name, s := range map[string]NodeStorage[int]{"memory": ms, "paged": ps} {
+		t1.Run(name, func(t1 *testing.T) {
+			t, _ := NewTree[int](2, s)
+			for i := 0; i < 50; i++ {
+				t.Insert(i)
+			}
+
+			// process is stopped while snapshot exists: old versions of nodes are deleted when tree is opened again
+			t.Snapshot()
+			for i := 0; i < 50; i += 2 {
+				t.Delete(i)
+			}
+			if name == "paged" {
+				ps.Close()
+				ps, _ = OpenPagedFileStorage[int](fileName, 2)
+				defer ps.Close()
+				s = ps
+			}
+
+			t, err := NewTree[int](2, s)
+			if err != nil {
+				t1.Fatalf("NewTree() error = %v", err)
+			}
+			names, _ := s.(nodeLister).nodeNames()
+			if len(names) != t.NodeCount() {
+				t1.Errorf("amount of nodes in storage got = %v, want %v", len(names), t.NodeCount())
+			}
+			if report, err := t.Verify(); err != nil || !report.OK() {
+				t1.Errorf("Verify() got = %v, %v, want no violations", report, err)
+			}
+			if got := collectKeys(t.All()); len(got) != 25 || got[0] != 1 {
+				t1.Errorf("All() got = %v", got)
+			}
+		})
+	}
+	if len(ms.refs) != 0 {
+		t1.Errorf("references of memory storage got = %v, want empty", ms.refs)
+	}
+}
+
+// collectKeys - returns all keys of iterator
+func collectKeys(seq func(yield func(int, error) bool)) []int {
+	var keys []int
+	for k, err := range seq {
+		if err != nil {
+			panic(err)
+		}
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// makeRange - returns keys from lo to hi (not included)
+func makeRange(lo, hi int) []int {
+	keys := make([]int, 0, hi-lo)
+	for i := lo; i < hi; i++ {
+		keys = append(keys, i)
+	}
+
+	return keys
+}
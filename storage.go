@@ -1,15 +1,107 @@
 package btree
 
 import (
-	"golang.org/x/exp/constraints"
+	"fmt"
+	"reflect"
+	"time"
 )
 
+// ManifestVersion - is a version of Manifest format
+const ManifestVersion = 1
+
 // RootName - is name of root Node
 const RootName = "0"
 
-type NodeStorage[V constraints.Ordered] interface {
+// NodeStorage is an interface of storage where Tree's nodes are kept.
+// AllocName returns a name for new Node. Storage must not return the same name again while Node with it exists
+type NodeStorage[V any] interface {
 	Name() string
+	AllocName() (string, error)
 	Read(name string) (*Node[V], error)
 	Write(n *Node[V]) error
 	Delete(name string) error
 }
+
+// RefStorage is a BatchStorage which also keeps amounts of references to nodes which are shared by Tree and its clones.
+// Node without saved amount is referenced once. Apply saves Batch.Refs together with nodes: amount 1 removes saved amount,
+// deleted nodes lose their amounts. If storage doesn't implement it, Tree can't be cloned.
+// Storage which wraps other storage returns errors.ErrUnsupported if wrapped storage doesn't keep references
+type RefStorage[V any] interface {
+	BatchStorage[V]
+	ReadRefs() (map[string]int, error)
+}
+
+// Meta is the structure of Tree's metadata.
+// Len is an amount of keys in Tree
+// Height is an amount of levels in Tree
+// NodeCount is an amount of nodes in Tree
+type Meta struct {
+	Len       int
+	Height    int
+	NodeCount int
+}
+
+// MetaStorage is a NodeStorage which also keeps Tree's metadata.
+// If storage doesn't implement it, Tree calculates metadata walking through all nodes on creation
+type MetaStorage[V any] interface {
+	NodeStorage[V]
+	ReadMeta() (Meta, error)
+	WriteMeta(m Meta) error
+}
+
+// emptyMeta - returns metadata of empty Tree (it has only empty root)
+func emptyMeta() Meta {
+	return Meta{Len: 0, Height: 1, NodeCount: 1}
+}
+
+// Manifest is the structure of Tree's parameters which are saved in storage.
+// Version is a version of Manifest format
+// T is a min degree of b-tree
+// KeyType is a name of Tree's key type
+// Created is a time of storage creation
+// Codec is a name of nodes' encoding (it's empty if storage doesn't encode nodes)
+type Manifest struct {
+	Version int
+	T       int
+	KeyType string
+	Created time.Time
+	Codec   string `json:",omitempty"`
+}
+
+// ManifestStorage is a NodeStorage which also keeps Tree's parameters.
+// NewTree checks that they are the same as parameters of created Tree.
+// Storage which wraps other storage returns errors.ErrUnsupported if wrapped storage doesn't keep manifest
+type ManifestStorage[V any] interface {
+	NodeStorage[V]
+	Manifest() (Manifest, error)
+}
+
+// newManifest - returns Manifest of Tree with min degree t and key type V
+func newManifest[V any](t int) Manifest {
+	return Manifest{
+		Version: ManifestVersion,
+		T:       t,
+		KeyType: keyTypeName[V](),
+		Created: time.Now().UTC(),
+	}
+}
+
+// checkManifest - returns an error if Manifest doesn't match Tree with min degree t and key type V
+func checkManifest[V any](m Manifest, t int) error {
+	if m.Version != ManifestVersion {
+		return fmt.Errorf("manifest version %d is not supported, expected %d", m.Version, ManifestVersion)
+	}
+	if m.T != t {
+		return fmt.Errorf("saved min degree is %d, but %d was requested", m.T, t)
+	}
+	if keyType := keyTypeName[V](); m.KeyType != keyType {
+		return fmt.Errorf("saved key type is %s, but %s was requested", m.KeyType, keyType)
+	}
+
+	return nil
+}
+
+// keyTypeName - returns name of key type
+func keyTypeName[V any]() string {
+	return reflect.TypeFor[V]().String()
+}
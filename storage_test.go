@@ -0,0 +1,48 @@
+package btree
+
+import "errors"
+
+// testStorage - MemoryStorage for tests which counts reads and writes and keeps applied batches.
+// Reading returns an error after failAfter reads (if failAfter > 0), writing returns an error after failWriteAfter
+// writes (if failWriteAfter > 0), applying returns applyErr (if it's set)
+type testStorage struct {
+	*MemoryStorage[int]
+	reads          int
+	failAfter      int
+	writes         int
+	failWriteAfter int
+	batches        []Batch[int]
+	applyErr       error
+}
+
+func (ts *testStorage) Read(name string) (*Node[int], error) {
+	ts.reads++
+	if ts.failAfter > 0 && ts.reads > ts.failAfter {
+		return nil, errors.New("read error")
+	}
+
+	return ts.MemoryStorage.Read(name)
+}
+
+func (ts *testStorage) Write(n *Node[int]) error {
+	ts.writes++
+	if ts.failWriteAfter > 0 && ts.writes > ts.failWriteAfter {
+		return errors.New("write error")
+	}
+
+	return ts.MemoryStorage.Write(n)
+}
+
+func (ts *testStorage) Apply(b Batch[int]) error {
+	if ts.applyErr != nil {
+		return ts.applyErr
+	}
+	ts.batches = append(ts.batches, b)
+
+	return ts.MemoryStorage.Apply(b)
+}
+
+// plainStorage - storage which implements only NodeStorage interface
+type plainStorage struct {
+	NodeStorage[int]
+}
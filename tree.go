@@ -1,16 +1,39 @@
 package btree
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
-	"strconv"
+	"os"
+	"sync"
 
 	"golang.org/x/exp/constraints"
 )
 
-type Tree[V constraints.Ordered] struct {
+var (
+	// errKeyExists - internal error of inserting key which Tree already has
+	errKeyExists = errors.New("key already exists")
+	// errKeyNotFound - internal error of deleting key which Tree doesn't have
+	errKeyNotFound = errors.New("not found Node with key")
+)
+
+// Tree is a B-tree which keeps its nodes in NodeStorage.
+// Tree can be used by several goroutines: readers work in parallel, writers work one by one
+type Tree[V any] struct {
+	mu      sync.RWMutex
 	storage NodeStorage[V]
 	t       int
+	compare func(a, b V) int
+	meta    Meta
+	version uint64 // amount of applied changes, it's used for detecting conflicts of transactions
+	root    string // name of root Node: RootName or name of clone's root
+	refs    *refTable
+}
+
+// pathFrame - internal structure: Node on the path from root and index of child on the path in it
+type pathFrame[V any] struct {
+	node *Node[V]
+	i    int
 }
 
 // NewTree is a function for creation empty tree
@@ -18,20 +41,163 @@ type Tree[V constraints.Ordered] struct {
 // - param t is a min degree of b-tree. It can't be less than 2
 // - param s is a storage when will be saved tree data
 func NewTree[V constraints.Ordered](t int, s NodeStorage[V]) (*Tree[V], error) {
+	return NewTreeFunc[V](t, cmp.Compare[V], s)
+}
+
+// NewTreeFunc is a function for creation empty tree with custom comparator of keys
+// - type V can be any type which can be saved in storage
+// - param t is a min degree of b-tree. It can't be less than 2
+// - param compare returns a negative number when a < b, a positive number when a > b and zero when a == b
+// - param s is a storage when will be saved tree data
+func NewTreeFunc[V any](t int, compare func(a, b V) int, s NodeStorage[V]) (*Tree[V], error) {
 	if t < 2 {
 		return nil, errors.New("t can't be less than 2")
 	}
 
-	return &Tree[V]{
+	if compare == nil {
+		return nil, errors.New("compare function can't be nil")
+	}
+
+	if ms, ok := s.(ManifestStorage[V]); ok {
+		m, err := ms.Manifest()
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			// storage wraps other storage which doesn't keep manifest
+		case err != nil:
+			return nil, err
+		default:
+			if err = checkManifest[V](m, t); err != nil {
+				return nil, fmt.Errorf("storage %s doesn't match tree: %w", s.Name(), err)
+			}
+		}
+	}
+
+	refs, err := newRefTable[V](s)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Tree[V]{
 		t:       t,
 		storage: s,
-	}, nil
+		compare: compare,
+		root:    RootName,
+		refs:    refs,
+	}
+	if err := tree.loadMeta(); err != nil {
+		return nil, err
+	}
+	if err := tree.reclaim(); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// Open is a function for opening a tree which was saved earlier in DiskStorage
+// - type V should be the same as key type of saved tree
+// - param path is a folder where files of tree are saved
+// - param t is a min degree of b-tree. It should be the same as t of saved tree
+func Open[V constraints.Ordered](path string, t int) (*Tree[V], error) {
+	s, err := OpenDiskStorage[V](path, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTree[V](t, s)
+}
+
+// Len is a function which returns amount of keys in Tree
+func (t *Tree[V]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.meta.Len
+}
+
+// Height is a function which returns amount of levels in Tree
+func (t *Tree[V]) Height() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.meta.Height
+}
+
+// NodeCount is a function which returns amount of nodes in Tree
+func (t *Tree[V]) NodeCount() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.meta.NodeCount
+}
+
+// loadMeta - internal function: reads metadata from storage.
+// If storage doesn't keep metadata or Tree is a clone - calculates it walking through all nodes
+func (t *Tree[V]) loadMeta() error {
+	if ms, ok := t.storage.(MetaStorage[V]); ok && t.root == RootName {
+		m, err := ms.ReadMeta()
+		if err == nil {
+			t.meta = m
+			return nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	root, err := t.storage.Read(t.root)
+	if err != nil {
+		return err
+	}
+
+	t.meta = Meta{Len: root.size(), Height: 1}
+	for n := root; !n.Leaf; t.meta.Height++ {
+		if n, err = t.storage.Read(n.Children[0]); err != nil {
+			return err
+		}
+	}
+
+	t.meta.NodeCount, err = t.countNodes(root)
+
+	return err
+}
+
+// countNodes - internal function for counting nodes in subtree of Node n
+func (t *Tree[V]) countNodes(n *Node[V]) (int, error) {
+	count := 1
+	for _, name := range n.Children {
+		c, err := t.storage.Read(name)
+		if err != nil {
+			return 0, err
+		}
+
+		cc, err := t.countNodes(c)
+		if err != nil {
+			return 0, err
+		}
+		count += cc
+	}
+
+	return count, nil
+}
+
+// writeMeta - internal function for saving metadata to storage (if storage keeps metadata).
+// Metadata in storage belongs to Tree with root RootName: clones keep it only in memory
+func (t *Tree[V]) writeMeta() error {
+	if ms, ok := t.storage.(MetaStorage[V]); ok && t.root == RootName {
+		return ms.WriteMeta(t.meta)
+	}
+
+	return nil
 }
 
 // Exists is a function for searching key in Tree. If key exists in tree - returns true, else - returns false
 // - param k should be `ordered type` (`int`, `string`, `float` etc.)
 func (t *Tree[V]) Exists(k V) (bool, error) {
-	root, err := t.storage.Read(RootName)
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	root, err := t.storage.Read(t.root)
 	if err != nil {
 		return false, err
 	}
@@ -44,30 +210,105 @@ func (t *Tree[V]) Exists(k V) (bool, error) {
 	return s != nil, nil
 }
 
-// Insert is a function for inserting element into Tree
+// Insert is a function for inserting element into Tree.
+// All nodes changed by inserting are saved to storage together. If Tree already has this key, nothing is changed
 // - param k should be `ordered type` (`int`, `string`, `float` etc.)
 func (t *Tree[V]) Insert(k V) error {
-	root, err := t.storage.Read(RootName)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.update(Op[V]{Kind: OpInsert, Key: k}, func(t *Tree[V]) error {
+		return t.insert(k, nil)
+	})
+	if errors.Is(err, errKeyExists) {
+		return nil
+	}
+
+	return err
+}
+
+// get - internal function: returns value of key k and a sign that key was found
+func (t *Tree[V]) get(k V) ([]byte, bool, error) {
+	root, err := t.storage.Read(t.root)
+	if err != nil {
+		return nil, false, err
+	}
+
+	n, i, err := t.search(root, k)
+	if err != nil || n == nil {
+		return nil, false, err
+	}
+
+	return n.value(i), true, nil
+}
+
+// put - internal function: replaces value of key k if key exists, else inserts key with value.
+// Returns previous value and a sign that key was replaced
+func (t *Tree[V]) put(k V, v []byte) ([]byte, bool, error) {
+	root, err := t.storage.Read(t.root)
+	if err != nil {
+		return nil, false, err
+	}
+
+	n, i, err := t.search(root, k)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if n == nil {
+		return nil, false, t.insert(k, v)
+	}
+
+	old := n.value(i)
+	n.setKey(i, k, v)
+
+	return old, true, t.storage.Write(n)
+}
+
+// insert - internal function for inserting key with value (value can be nil) into Tree.
+// Returns errKeyExists if Tree already has the key: full nodes on its path can be split, but amounts aren't changed
+func (t *Tree[V]) insert(k V, v []byte) error {
+	if err := t.insertKey(k, v); err != nil {
+		return err
+	}
+	t.meta.Len++
+
+	return t.writeMeta()
+}
+
+// insertKey - internal function for inserting key with value to nodes of Tree starting from root
+func (t *Tree[V]) insertKey(k V, v []byte) error {
+	root, err := t.storage.Read(t.root)
 	if err != nil {
 		return err
 	}
 
 	if len(root.Keys) == t.maxKeysLength() {
-		s := NewNode[V](t.t, RootName)
+		// root keeps its name: old root's keys are moved to new Node
+		name, err := t.storage.AllocName()
+		if err != nil {
+			return err
+		}
+		root.Name = name
+
+		s := NewNode[V](t.t, t.root)
 		s.Leaf = false
-		s.Children = append(s.Children, RootName+RootName)
+		s.Children = append(s.Children, name)
+		s.Counts = append(s.Counts, root.size())
+		t.meta.Height++
+		t.meta.NodeCount++
 		if err := t.splitChild(s, root, 0); err != nil {
 			return err
 		}
 
-		if err := t.insertNonFull(s, k); err != nil {
+		if err := t.insertNonFull(s, k, v); err != nil {
 			return err
 		}
 
 		return nil
 	}
 
-	if err := t.insertNonFull(root, k); err != nil {
+	if err := t.insertNonFull(root, k, v); err != nil {
 		return err
 	}
 
@@ -79,15 +320,19 @@ func (t *Tree[V]) maxKeysLength() int {
 	return 2*t.t - 1
 }
 
-// insertNonFull - internal function for inserting key to a blank Node
-func (t *Tree[V]) insertNonFull(n *Node[V], k V) error {
+// insertNonFull - internal function for inserting key to a blank Node. Amount of keys in child's subtree is changed
+// after key is inserted to it, so nothing except splits is changed if key is found in subtree
+func (t *Tree[V]) insertNonFull(n *Node[V], k V, v []byte) error {
 	i := 0
-	for i < len(n.Keys) && k > n.Keys[i] {
+	for i < len(n.Keys) && t.compare(k, n.Keys[i]) > 0 {
 		i++
 	}
+	if i < len(n.Keys) && t.compare(k, n.Keys[i]) == 0 {
+		return fmt.Errorf("%w: %v", errKeyExists, k)
+	}
 
 	if n.Leaf {
-		n.insertKey(i, k)
+		n.insertKey(i, k, v)
 		return t.storage.Write(n)
 	}
 
@@ -101,7 +346,10 @@ func (t *Tree[V]) insertNonFull(n *Node[V], k V) error {
 		if err := t.splitChild(n, c, i); err != nil {
 			return err
 		}
-		if i < len(n.Keys) && k > n.Keys[i] {
+		if t.compare(k, n.Keys[i]) == 0 {
+			return fmt.Errorf("%w: %v", errKeyExists, k)
+		}
+		if i < len(n.Keys) && t.compare(k, n.Keys[i]) > 0 {
 			i++
 			reReadChildren = true
 		}
@@ -109,24 +357,42 @@ func (t *Tree[V]) insertNonFull(n *Node[V], k V) error {
 
 	if reReadChildren {
 		c, err = t.storage.Read(n.Children[i])
+		if err != nil {
+			return err
+		}
 	}
 
-	return t.insertNonFull(c, k)
+	if err = t.insertNonFull(c, k, v); err != nil {
+		return err
+	}
+	n.Counts[i]++
+
+	return t.storage.Write(n)
 }
 
 // splitChild - internal function for splitting Node with full amount of keys to two nodes
 func (t *Tree[V]) splitChild(n, nodeToSplit *Node[V], i int) error {
-	middleKey := nodeToSplit.Keys[t.t-1]
-	n.insertKey(i, middleKey)
+	name, err := t.storage.AllocName()
+	if err != nil {
+		return err
+	}
+
+	middleKey, middleValue := nodeToSplit.Keys[t.t-1], nodeToSplit.value(t.t-1)
+	n.insertKey(i, middleKey, middleValue)
 
-	newNode := newSplitNode(t.t, nodeToSplit, n.Name+strconv.Itoa(i+1))
-	n.insertChild(i+1, newNode.Name)
+	newNode := newSplitNode(t.t, nodeToSplit, name)
+	n.insertChild(i+1, newNode.Name, newNode.size())
+	t.meta.NodeCount++
 
-	nodeToSplit.Name = n.Name + strconv.Itoa(i)
 	nodeToSplit.Keys = nodeToSplit.Keys[:t.t-1]
+	if nodeToSplit.Values != nil {
+		nodeToSplit.Values = nodeToSplit.Values[:t.t-1]
+	}
 	if !nodeToSplit.Leaf {
 		nodeToSplit.Children = nodeToSplit.Children[:t.t]
+		nodeToSplit.Counts = nodeToSplit.Counts[:t.t]
 	}
+	n.Counts[i] = nodeToSplit.size()
 
 	if err := t.storage.Write(n); err != nil {
 		return err
@@ -149,11 +415,11 @@ func (t *Tree[V]) search(n *Node[V], k V) (*Node[V], int, error) {
 	numKeys := len(n.Keys)
 
 	i := 0
-	for i < numKeys && k > n.Keys[i] {
+	for i < numKeys && t.compare(k, n.Keys[i]) > 0 {
 		i++
 	}
 
-	if i < numKeys && k == n.Keys[i] {
+	if i < numKeys && t.compare(k, n.Keys[i]) == 0 {
 		return n, i, nil
 	}
 
@@ -169,114 +435,314 @@ func (t *Tree[V]) search(n *Node[V], k V) (*Node[V], int, error) {
 	return t.search(c, k)
 }
 
-// Delete is a function for deleting Node by key in Tree
+// Delete is a function for deleting Node by key in Tree.
+// All nodes changed by deleting are saved to storage together
 // - param k should be `ordered type` (`int`, `string`, `float` etc.)
 // if Tree doesn't have this key - function returns an error
 func (t *Tree[V]) Delete(k V) error {
-	root, err := t.storage.Read(RootName)
-	if err != nil {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.update(Op[V]{Kind: OpDelete, Key: k}, func(t *Tree[V]) error {
+		_, err := t.delete(k)
 		return err
+	})
+}
+
+// delete - internal function for deleting key from Tree. Returns value of deleted key.
+// Key is deleted in one pass from root: before going down to a child, the child gets at least t keys
+// (a key is borrowed from a sibling or the child is merged with a sibling), so every Node except root
+// keeps at least t-1 keys after deleting
+func (t *Tree[V]) delete(k V) ([]byte, error) {
+	root, err := t.storage.Read(t.root)
+	if err != nil {
+		return nil, err
 	}
 
 	n, i, err := t.search(root, k)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if n == nil {
-		return errors.New(fmt.Sprintf("not found Node with key: %v", k))
+		return nil, fmt.Errorf("%w: %v", errKeyNotFound, k)
 	}
 
-	if n.Leaf {
-		n.deleteKeyByIndex(i)
+	value := n.value(i)
+	if err = t.deleteKey(root, k); err != nil {
+		return nil, err
+	}
+
+	if len(root.Keys) == 0 && !root.Leaf {
+		if err = t.collapseRoot(root); err != nil {
+			return nil, err
+		}
+	}
+	t.meta.Len--
+
+	return value, t.writeMeta()
+}
+
+// deleteKey - internal function for deleting key k from subtree of Node n.
+// Key should exist in subtree, n should have at least t keys (or be root)
+func (t *Tree[V]) deleteKey(n *Node[V], k V) error {
+	for {
+		i := 0
+		for i < len(n.Keys) && t.compare(k, n.Keys[i]) > 0 {
+			i++
+		}
+
+		if i < len(n.Keys) && t.compare(k, n.Keys[i]) == 0 {
+			if n.Leaf {
+				n.deleteKeyByIndex(i)
+				return t.storage.Write(n)
+			}
+			return t.deleteInternalKey(n, i)
+		}
+
+		c, i, err := t.prepareChild(n, i)
+		if err != nil {
+			return err
+		}
+
+		n.Counts[i]--
 		if err = t.storage.Write(n); err != nil {
 			return err
 		}
-		return nil
+		n = c
 	}
+}
 
+// deleteInternalKey - internal function for deleting key on the i-position from internal Node n
+func (t *Tree[V]) deleteInternalKey(n *Node[V], i int) error {
 	childLeft, err := t.storage.Read(n.Children[i])
 	if err != nil {
 		return err
 	}
 
+	// key is replaced by predecessor from left child
 	if len(childLeft.Keys) >= t.t {
-		predecessor := childLeft.deleteMaxKey()
-		n.Keys[i] = predecessor
-		if err = t.storage.Write(n); err != nil {
+		predecessor, predecessorValue, err := t.deleteEdgeKey(childLeft, true)
+		if err != nil {
 			return err
 		}
+		n.setKey(i, predecessor, predecessorValue)
+		n.Counts[i]--
 
-		if err = t.storage.Write(childLeft); err != nil {
-			return err
-		}
-
-		return nil
+		return t.storage.Write(n)
 	}
 
 	childRight, err := t.storage.Read(n.Children[i+1])
 	if err != nil {
 		return err
 	}
+
+	// key is replaced by successor from right child
 	if len(childRight.Keys) >= t.t {
-		successor := childRight.deleteMinKey()
-		n.Keys[i] = successor
-		if err = t.storage.Write(n); err != nil {
+		successor, successorValue, err := t.deleteEdgeKey(childRight, false)
+		if err != nil {
 			return err
 		}
+		n.setKey(i, successor, successorValue)
+		n.Counts[i+1]--
 
-		if err = t.storage.Write(childRight); err != nil {
-			return err
+		return t.storage.Write(n)
+	}
+
+	// both children have t-1 keys: key goes down to merged Node and is deleted from it
+	k := n.Keys[i]
+	if err = t.mergeNodes(n, i, childLeft, childRight); err != nil {
+		return err
+	}
+	n.Counts[i]--
+	if err = t.storage.Write(n); err != nil {
+		return err
+	}
+
+	return t.deleteKey(childLeft, k)
+}
+
+// deleteEdgeKey - internal function for deleting max (if param last is true) or min key from subtree of Node n.
+// n should have at least t keys. Returns deleted key and its value
+func (t *Tree[V]) deleteEdgeKey(n *Node[V], last bool) (V, []byte, error) {
+	for !n.Leaf {
+		i := 0
+		if last {
+			i = len(n.Children) - 1
 		}
 
-		return nil
+		c, i, err := t.prepareChild(n, i)
+		if err != nil {
+			var k V
+			return k, nil, err
+		}
+
+		n.Counts[i]--
+		if err = t.storage.Write(n); err != nil {
+			var k V
+			return k, nil, err
+		}
+		n = c
+	}
+
+	var k V
+	var v []byte
+	if last {
+		k, v = n.deleteMaxKey()
+	} else {
+		k, v = n.deleteMinKey()
 	}
 
-	return t.mergeNodes(n, i)
+	return k, v, t.storage.Write(n)
 }
 
-// mergeNodes is an internal function for merging two nodes to one Node in Tree
-func (t *Tree[V]) mergeNodes(n *Node[V], i int) error {
-	leftChild, err := t.storage.Read(n.Children[i])
+// prepareChild - internal function: makes sure that child on the i-position of Node n has at least t keys
+// before going down to it. Returns the child and its position (it's changed if child was merged with left sibling).
+// Node n isn't written to storage: caller should do it
+func (t *Tree[V]) prepareChild(n *Node[V], i int) (*Node[V], int, error) {
+	c, err := t.storage.Read(n.Children[i])
 	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(c.Keys) >= t.t {
+		return c, i, nil
+	}
+
+	var left, right *Node[V]
+	if i > 0 {
+		if left, err = t.storage.Read(n.Children[i-1]); err != nil {
+			return nil, 0, err
+		}
+		if len(left.Keys) >= t.t {
+			return c, i, t.moveFromLeft(n, i, left, c)
+		}
+	}
+
+	if i < len(n.Keys) {
+		if right, err = t.storage.Read(n.Children[i+1]); err != nil {
+			return nil, 0, err
+		}
+		if len(right.Keys) >= t.t {
+			return c, i, t.moveFromRight(n, i, c, right)
+		}
+
+		return c, i, t.mergeNodes(n, i, c, right)
+	}
+
+	return left, i - 1, t.mergeNodes(n, i-1, left, c)
+}
+
+// moveFromLeft - internal function: moves key from Node n to the beginning of its child c (on the i-position)
+// and the max key of left sibling to Node n
+func (t *Tree[V]) moveFromLeft(n *Node[V], i int, left, c *Node[V]) error {
+	c.insertKey(0, n.Keys[i-1], n.value(i-1))
+	k, v := left.deleteMaxKey()
+	n.setKey(i-1, k, v)
+
+	moved := 1
+	if !c.Leaf {
+		last := len(left.Children) - 1
+		c.insertChild(0, left.Children[last], left.Counts[last])
+		moved += left.Counts[last]
+		left.Children = left.Children[:last]
+		left.Counts = left.Counts[:last]
+	}
+	n.Counts[i-1] -= moved
+	n.Counts[i] += moved
+
+	if err := t.storage.Write(left); err != nil {
 		return err
 	}
-	rightChild, err := t.storage.Read(n.Children[i+1])
-	if err != nil {
+
+	return t.storage.Write(c)
+}
+
+// moveFromRight - internal function: moves key from Node n to the end of its child c (on the i-position)
+// and the min key of right sibling to Node n
+func (t *Tree[V]) moveFromRight(n *Node[V], i int, c, right *Node[V]) error {
+	c.insertKey(len(c.Keys), n.Keys[i], n.value(i))
+	k, v := right.deleteMinKey()
+	n.setKey(i, k, v)
+
+	moved := 1
+	if !c.Leaf {
+		c.insertChild(len(c.Children), right.Children[0], right.Counts[0])
+		moved += right.Counts[0]
+		right.Children = right.Children[1:]
+		right.Counts = right.Counts[1:]
+	}
+	n.Counts[i] += moved
+	n.Counts[i+1] -= moved
+
+	if err := t.storage.Write(right); err != nil {
 		return err
 	}
 
+	return t.storage.Write(c)
+}
+
+// mergeNodes is an internal function for merging two children of Node n (on the i and i+1 positions)
+// and key between them to one Node. Right child is deleted from storage
+func (t *Tree[V]) mergeNodes(n *Node[V], i int, leftChild, rightChild *Node[V]) error {
+	leftChild.insertKey(len(leftChild.Keys), n.Keys[i], n.value(i))
+	if leftChild.Values != nil || rightChild.Values != nil {
+		// node without values keeps nil Values, so missing values are padded with nils like in insertKey
+		if leftChild.Values == nil {
+			leftChild.Values = make([][]byte, len(leftChild.Keys))
+		}
+		values := rightChild.Values
+		if values == nil {
+			values = make([][]byte, len(rightChild.Keys))
+		}
+		leftChild.Values = append(leftChild.Values, values...)
+	}
 	leftChild.Keys = append(leftChild.Keys, rightChild.Keys...)
 
 	if !leftChild.Leaf {
 		leftChild.Children = append(leftChild.Children, rightChild.Children...)
+		leftChild.Counts = append(leftChild.Counts, rightChild.Counts...)
 	}
 
-	if err = t.storage.Write(leftChild); err != nil {
+	n.deleteKeyByIndex(i)
+	n.Children = append(n.Children[:i+1], n.Children[i+2:]...)
+	n.Counts = append(n.Counts[:i+1], n.Counts[i+2:]...)
+	n.Counts[i] = leftChild.size()
+
+	if err := t.storage.Write(leftChild); err != nil {
 		return err
 	}
 
-	n.Keys = append(n.Keys[:i], n.Keys[i+1:]...)
-	n.Children = append(n.Children[:i+1], n.Children[i+2:]...)
+	if err := t.storage.Delete(rightChild.Name); err != nil {
+		return err
+	}
+	t.meta.NodeCount--
 
-	if len(n.Keys) == 0 {
-		n.Keys = leftChild.Keys
-		n.Children = leftChild.Children
-		if len(n.Children) == 0 {
-			n.Leaf = true
-		}
-		if err = t.storage.Delete(leftChild.Name); err != nil {
-			return err
-		}
+	return nil
+}
+
+// collapseRoot - internal function: root without keys is replaced by its only child
+func (t *Tree[V]) collapseRoot(root *Node[V]) error {
+	c, err := t.storage.Read(root.Children[0])
+	if err != nil {
+		return err
 	}
 
-	if err = t.storage.Write(n); err != nil {
+	root.Keys = c.Keys
+	root.Values = c.Values
+	root.Children = c.Children
+	root.Counts = c.Counts
+	root.Leaf = c.Leaf
+
+	if err = t.storage.Write(root); err != nil {
 		return err
 	}
 
-	if err = t.storage.Delete(rightChild.Name); err != nil {
+	if err = t.storage.Delete(c.Name); err != nil {
 		return err
 	}
+	t.meta.NodeCount--
+	t.meta.Height--
 
 	return nil
 }
@@ -1,9 +1,14 @@
 package btree
 
 import (
+	"bytes"
+	"math/rand"
 	"os"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/exp/constraints"
 )
@@ -38,7 +43,11 @@ func TestNewTreeStorage(t *testing.T) {
 				t: 2,
 				storage: &DiskStorage[int]{
 					folderName: "success_creating_empty_tree",
+					codec:      JSONCodec[int]{},
 				},
+				meta: Meta{Len: 0, Height: 1, NodeCount: 1},
+				root: RootName,
+				refs: &refTable{saved: true, counts: map[string]int{}, pins: map[string]int{}},
 			},
 			wantErr: false,
 		},
@@ -51,6 +60,13 @@ func TestNewTreeStorage(t *testing.T) {
 				t.Errorf("NewTree() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if got != nil {
+				// functions can't be compared by reflect.DeepEqual
+				if got.compare == nil {
+					t.Errorf("NewTree() compare function is nil")
+				}
+				got.compare = nil
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewTree() got = %v, want %v", got, tt.want)
 			}
@@ -228,23 +244,24 @@ func TestTreStorage_Insert_to_full_node(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{3, 2},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F"},
 				Children: []string{},
 				Leaf:     true,
@@ -267,23 +284,24 @@ func TestTreStorage_Insert_split_leaf(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{3, 2},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F"},
 				Children: []string{},
 				Leaf:     true,
@@ -305,23 +323,24 @@ func TestTreStorage_Insert_split_leaf(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{3, 5},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F", "G", "K", "M"},
 				Children: []string{},
 				Leaf:     true,
@@ -339,32 +358,33 @@ func TestTreStorage_Insert_split_leaf(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D", "G"},
-				Children: []string{"00", "01", "02"},
+				Children: []string{"1", "2", "3"},
+				Counts:   []int{3, 2, 3},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "02",
+			nodeName: "3",
 			want: &Node[string]{
-				Name:     "02",
+				Name:     "3",
 				Keys:     []string{"K", "M", "S"},
 				Children: []string{},
 				Leaf:     true,
@@ -476,23 +496,24 @@ func TestTreeStorage_Delete_delete_key_from_left_child(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{3, 5},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F", "G", "K", "M"},
 				Children: []string{},
 				Leaf:     true,
@@ -512,23 +533,24 @@ func TestTreeStorage_Delete_delete_key_from_left_child(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{2, 5},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F", "G", "K", "M"},
 				Children: []string{},
 				Leaf:     true,
@@ -550,23 +572,24 @@ func TestTreeStorage_Delete_delete_key_from_right_child(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{3, 5},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F", "G", "K", "M"},
 				Children: []string{},
 				Leaf:     true,
@@ -586,23 +609,24 @@ func TestTreeStorage_Delete_delete_key_from_right_child(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{3, 4},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F", "K", "M"},
 				Children: []string{},
 				Leaf:     true,
@@ -624,23 +648,24 @@ func TestTreeStorage_Delete_get_key_from_left_child(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{3, 5},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F", "G", "K", "M"},
 				Children: []string{},
 				Leaf:     true,
@@ -660,23 +685,24 @@ func TestTreeStorage_Delete_get_key_from_left_child(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"C"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{2, 5},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F", "G", "K", "M"},
 				Children: []string{},
 				Leaf:     true,
@@ -698,32 +724,33 @@ func TestTreeStorage_Delete_get_key_from_right_child(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D", "G"},
-				Children: []string{"00", "01", "02"},
+				Children: []string{"1", "2", "3"},
+				Counts:   []int{3, 2, 4},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "02",
+			nodeName: "3",
 			want: &Node[string]{
-				Name:     "02",
+				Name:     "3",
 				Keys:     []string{"K", "M", "N", "O"},
 				Children: []string{},
 				Leaf:     true,
@@ -743,32 +770,33 @@ func TestTreeStorage_Delete_get_key_from_right_child(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D", "K"},
-				Children: []string{"00", "01", "02"},
+				Children: []string{"1", "2", "3"},
+				Counts:   []int{3, 2, 3},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "02",
+			nodeName: "3",
 			want: &Node[string]{
-				Name:     "02",
+				Name:     "3",
 				Keys:     []string{"M", "N", "O"},
 				Children: []string{},
 				Leaf:     true,
@@ -790,23 +818,24 @@ func TestTreeStorage_Delete_merge_nodes(t1 *testing.T) {
 			want: &Node[string]{
 				Name:     "0",
 				Keys:     []string{"D"},
-				Children: []string{"00", "01"},
+				Children: []string{"1", "2"},
+				Counts:   []int{3, 2},
 				Leaf:     false,
 			},
 		},
 		{
-			nodeName: "00",
+			nodeName: "1",
 			want: &Node[string]{
-				Name:     "00",
+				Name:     "1",
 				Keys:     []string{"A", "B", "C"},
 				Children: []string{},
 				Leaf:     true,
 			},
 		},
 		{
-			nodeName: "01",
+			nodeName: "2",
 			want: &Node[string]{
-				Name:     "01",
+				Name:     "2",
 				Keys:     []string{"E", "F"},
 				Children: []string{},
 				Leaf:     true,
@@ -834,13 +863,17 @@ func TestTreeStorage_Delete_merge_nodes(t1 *testing.T) {
 	// check tree structure after deleting
 	checkTreeStructure(t, t1, validTreeAfterDeleting)
 
-	_, err := t.storage.Read("00")
+	if t.Len() != 4 || t.Height() != 1 || t.NodeCount() != 1 {
+		t1.Errorf("Meta got = %v, %v, %v, want 4, 1, 1", t.Len(), t.Height(), t.NodeCount())
+	}
+
+	_, err := t.storage.Read("1")
 	if err == nil {
-		t1.Errorf("Node 00 exists")
+		t1.Errorf("Node 1 exists")
 	}
-	_, err = t.storage.Read("01")
+	_, err = t.storage.Read("2")
 	if err == nil {
-		t1.Errorf("Node 01 exists")
+		t1.Errorf("Node 2 exists")
 	}
 }
 
@@ -872,3 +905,267 @@ func checkTreeStructure(t *Tree[string], t1 *testing.T, treeData []validNodeStor
 		}
 	}
 }
+
+func TestTreeStorage_Meta(t1 *testing.T) {
+	testFolder := "tree_meta"
+	defer os.RemoveAll(testFolder)
+	t := createTreeStorage(3, []string{"A", "B", "D", "E", "F", "C", "G", "K", "M", "N", "O"}, testFolder)
+
+	checkMeta := func(t *Tree[string], want Meta) {
+		if got := (Meta{Len: t.Len(), Height: t.Height(), NodeCount: t.NodeCount()}); got != want {
+			t1.Errorf("Meta got = %+v, want %+v", got, want)
+		}
+	}
+	checkMeta(t, Meta{Len: 11, Height: 2, NodeCount: 4})
+
+	t.Delete("G")
+	t.Delete("D")
+	checkMeta(t, Meta{Len: 9, Height: 2, NodeCount: 4})
+
+	// metadata should be read from storage by new Tree
+	t2, err := NewTree[string](3, t.storage)
+	if err != nil {
+		t1.Fatalf("NewTree() error = %v", err)
+	}
+	checkMeta(t2, Meta{Len: 9, Height: 2, NodeCount: 4})
+
+	// without metadata file Tree calculates it walking through nodes
+	os.Remove(testFolder + "/" + metaFileName + ".json")
+	t3, err := NewTree[string](3, t.storage)
+	if err != nil {
+		t1.Fatalf("NewTree() error = %v", err)
+	}
+	checkMeta(t3, Meta{Len: 9, Height: 2, NodeCount: 4})
+}
+
+func TestNewTreeFunc(t1 *testing.T) {
+	testFolder := "tree_func"
+	defer os.RemoveAll(testFolder)
+
+	if _, err := NewTreeFunc[[]byte](3, nil, nil); err == nil {
+		t1.Errorf("NewTreeFunc() with nil compare expected error")
+	}
+
+	s, err := NewDiskStorage[[]byte](testFolder, 3)
+	if err != nil {
+		t1.Fatalf("NewDiskStorage() error = %v", err)
+	}
+	t, err := NewTreeFunc[[]byte](3, bytes.Compare, s)
+	if err != nil {
+		t1.Fatalf("NewTreeFunc() error = %v", err)
+	}
+
+	for _, k := range []string{"k", "c", "x", "a", "m", "b", "z", "d"} {
+		if err := t.Insert([]byte(k)); err != nil {
+			t1.Fatalf("Insert() error = %v", err)
+		}
+	}
+	t.Delete([]byte("m"))
+
+	var got []string
+	for k, err := range t.All() {
+		if err != nil {
+			t1.Fatalf("All() error = %v", err)
+		}
+		got = append(got, string(k))
+	}
+	if want := []string{"a", "b", "c", "d", "k", "x", "z"}; !reflect.DeepEqual(got, want) {
+		t1.Errorf("All() got = %v, want %v", got, want)
+	}
+
+	if ok, _ := t.Exists([]byte("x")); !ok {
+		t1.Errorf("Exists(x) got = false, want true")
+	}
+	if k, ok, _ := t.Ceiling([]byte("e")); !ok || string(k) != "k" {
+		t1.Errorf("Ceiling(e) got = %s, %v, want k", k, ok)
+	}
+}
+
+func TestNewMapFunc_struct_keys(t1 *testing.T) {
+	testFolder := "map_func_struct_keys"
+	defer os.RemoveAll(testFolder)
+
+	type event struct {
+		At   time.Time
+		Name string
+	}
+	compare := func(a, b event) int {
+		if c := a.At.Compare(b.At); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	}
+
+	s, _ := NewDiskStorage[event](testFolder, 3)
+	m, err := NewMapFunc[event, string](3, compare, s)
+	if err != nil {
+		t1.Fatalf("NewMapFunc() error = %v", err)
+	}
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		m.Put(event{At: start.Add(time.Duration(i) * time.Hour), Name: "e"}, strconv.Itoa(i))
+	}
+
+	for i := 0; i < 10; i++ {
+		got, ok, err := m.Get(event{At: start.Add(time.Duration(i) * time.Hour), Name: "e"})
+		if err != nil || !ok || got != strconv.Itoa(i) {
+			t1.Errorf("Get() got = %v, %v, %v, want %v", got, ok, err, i)
+		}
+	}
+}
+
+func TestTreeStorage_Insert_deep_tree(t1 *testing.T) {
+	testFolder := "insert_deep_tree"
+	defer os.RemoveAll(testFolder)
+
+	keys := rand.New(rand.NewSource(1)).Perm(300)
+	t := createIntTreeStorage(2, keys[:200], testFolder)
+	if t.Height() < 4 {
+		t1.Fatalf("Height() got = %v, want at least 4", t.Height())
+	}
+
+	// reopened storage should allocate names which aren't used by existing nodes
+	t, err := Open[int](testFolder, 2)
+	if err != nil {
+		t1.Fatalf("Open() error = %v", err)
+	}
+	for _, k := range keys[200:] {
+		if err := t.Insert(k); err != nil {
+			t1.Fatalf("Insert() error = %v", err)
+		}
+	}
+
+	want := 0
+	for k, err := range t.All() {
+		if err != nil {
+			t1.Fatalf("All() error = %v", err)
+		}
+		if k != want {
+			t1.Fatalf("All() got = %v, want %v", k, want)
+		}
+		want++
+	}
+	if want != 300 || t.Len() != 300 {
+		t1.Errorf("All() got %v keys, Len() = %v, want 300", want, t.Len())
+	}
+}
+
+func TestTreeStorage_Insert_existing_key(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	ts := &testStorage{MemoryStorage: ms}
+	t, _ := NewTree[int](2, ts)
+	keys := rand.New(rand.NewSource(1)).Perm(100)
+	for _, k := range keys {
+		t.Insert(k)
+	}
+
+	// existing key isn't inserted again and nothing is applied (like KeyPresent of InsertMany)
+	ts.batches = nil
+	for _, k := range keys {
+		if err := t.Insert(k); err != nil {
+			t1.Fatalf("Insert(%d) of existing key error = %v", k, err)
+		}
+	}
+	if t.Len() != 100 || len(ts.batches) != 0 {
+		t1.Errorf("after inserting existing keys Len() got = %v and %v batches were applied", t.Len(), len(ts.batches))
+	}
+	if report, err := t.Verify(); err != nil || !report.OK() {
+		t1.Errorf("Verify() got = %v, %v, want no violations", report, err)
+	}
+	checkTreeBalance(t, t1)
+}
+
+func TestTreeStorage_Delete_keeps_balance(t1 *testing.T) {
+	for _, degree := range []int{2, 3} {
+		testFolder := "delete_keeps_balance_" + strconv.Itoa(degree)
+		t1.Run(testFolder, func(t1 *testing.T) {
+			defer os.RemoveAll(testFolder)
+
+			r := rand.New(rand.NewSource(int64(degree)))
+			keys := r.Perm(200)
+			t := createIntTreeStorage(degree, keys, testFolder)
+
+			deleted := map[int]bool{}
+			for i, k := range r.Perm(200) {
+				if err := t.Delete(k); err != nil {
+					t1.Fatalf("Delete(%d) error = %v", k, err)
+				}
+				deleted[k] = true
+
+				if i%10 == 0 || i > 190 {
+					checkTreeBalance(t, t1)
+				}
+				if t.Len() != 200-len(deleted) {
+					t1.Fatalf("Len() got = %v, want %v", t.Len(), 200-len(deleted))
+				}
+			}
+
+			for _, k := range keys {
+				if ok, _ := t.Exists(k); ok {
+					t1.Errorf("Exists(%d) got = true after deleting", k)
+				}
+			}
+			if t.Height() != 1 || t.NodeCount() != 1 {
+				t1.Errorf("Height(), NodeCount() got = %v, %v, want 1, 1", t.Height(), t.NodeCount())
+			}
+		})
+	}
+}
+
+// checkTreeBalance checks that every Node except root has at least t-1 keys, all leaves are on the same level,
+// keys are ordered, counts of subtrees and metadata are correct
+func checkTreeBalance(t *Tree[int], t1 *testing.T) {
+	leafDepth := -1
+	nodes := 0
+	var walk func(name string, depth int, lo, hi *int) int
+	walk = func(name string, depth int, lo, hi *int) int {
+		n, err := t.storage.Read(name)
+		if err != nil {
+			t1.Fatalf("Error reading Node %s: %v", name, err)
+		}
+		nodes++
+
+		if name != t.root && len(n.Keys) < t.t-1 || len(n.Keys) > 2*t.t-1 {
+			t1.Fatalf("Node %s has %d keys", name, len(n.Keys))
+		}
+		for i, k := range n.Keys {
+			if i > 0 && n.Keys[i-1] >= k || lo != nil && k <= *lo || hi != nil && k >= *hi {
+				t1.Fatalf("Node %s has keys out of order: %v", name, n.Keys)
+			}
+		}
+
+		if n.Leaf {
+			if leafDepth == -1 {
+				leafDepth = depth
+			}
+			if depth != leafDepth {
+				t1.Fatalf("Leaf %s on depth %d, want %d", name, depth, leafDepth)
+			}
+			return len(n.Keys)
+		}
+
+		size := len(n.Keys)
+		for i, c := range n.Children {
+			var clo, chi *int
+			if i > 0 {
+				clo = &n.Keys[i-1]
+			}
+			if i < len(n.Keys) {
+				chi = &n.Keys[i]
+			}
+			count := walk(c, depth+1, clo, chi)
+			if n.Counts[i] != count {
+				t1.Fatalf("Node %s has count %d of child %s, want %d", name, n.Counts[i], c, count)
+			}
+			size += count
+		}
+
+		return size
+	}
+
+	size := walk(t.root, 1, nil, nil)
+	if size != t.Len() || leafDepth != t.Height() || nodes != t.NodeCount() {
+		t1.Fatalf("Meta got = %v, %v, %v, want %v, %v, %v", t.Len(), t.Height(), t.NodeCount(), size, leafDepth, nodes)
+	}
+}
@@ -0,0 +1,112 @@
+package btree
+
+import "errors"
+
+var (
+	// ErrTxnDone - error of using transaction after Commit or Rollback
+	ErrTxnDone = errors.New("transaction is already committed or rolled back")
+	// ErrTxnConflict - error of committing transaction when Tree was changed after transaction began
+	ErrTxnConflict = errors.New("tree was changed after transaction began")
+)
+
+// Txn is a transaction of Tree: a group of operations which are applied to storage together.
+// Changes of transaction are kept in memory and are visible only inside transaction until Commit.
+// Operation which returned an error doesn't change transaction. Txn can't be used by several goroutines
+type Txn[V any] struct {
+	tree    *Tree[V]
+	batch   *batchStorage[V]
+	view    *Tree[V]
+	version uint64
+	done    bool
+}
+
+// Begin is a function for starting transaction of Tree
+func (t *Tree[V]) Begin() *Txn[V] {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	b := newBatchStorage[V](t.storage)
+
+	return &Txn[V]{
+		tree:    t,
+		batch:   b,
+		view:    t.withStorage(b),
+		version: t.version,
+	}
+}
+
+// Insert is a function for inserting element into Tree inside transaction
+func (tx *Txn[V]) Insert(k V) error {
+	if tx.done {
+		return ErrTxnDone
+	}
+
+	tx.tree.mu.RLock()
+	defer tx.tree.mu.RUnlock()
+
+	return tx.view.Insert(k)
+}
+
+// Delete is a function for deleting element from Tree inside transaction.
+// If Tree doesn't have this key - function returns an error
+func (tx *Txn[V]) Delete(k V) error {
+	if tx.done {
+		return ErrTxnDone
+	}
+
+	tx.tree.mu.RLock()
+	defer tx.tree.mu.RUnlock()
+
+	return tx.view.Delete(k)
+}
+
+// Exists is a function for searching key in Tree with changes of transaction
+func (tx *Txn[V]) Exists(k V) (bool, error) {
+	if tx.done {
+		return false, ErrTxnDone
+	}
+
+	tx.tree.mu.RLock()
+	defer tx.tree.mu.RUnlock()
+
+	return tx.view.Exists(k)
+}
+
+// Len is a function which returns amount of keys in Tree with changes of transaction
+func (tx *Txn[V]) Len() int {
+	return tx.view.Len()
+}
+
+// Commit is a function for applying all changes of transaction to storage together.
+// If Tree was changed after transaction began - returns ErrTxnConflict and transaction is rolled back
+func (tx *Txn[V]) Commit() error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	tx.done = true
+
+	tx.tree.mu.Lock()
+	defer tx.tree.mu.Unlock()
+
+	if tx.tree.version != tx.version {
+		tx.batch.discard()
+		return ErrTxnConflict
+	}
+
+	if len(tx.batch.ops) == 0 {
+		return nil
+	}
+
+	return tx.tree.commit(tx.batch, tx.view.meta)
+}
+
+// Rollback is a function for discarding all changes of transaction
+func (tx *Txn[V]) Rollback() error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	tx.done = true
+	tx.batch.discard()
+
+	return nil
+}
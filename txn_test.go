@@ -0,0 +1,113 @@
+package btree
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestTxn_Commit(t1 *testing.T) {
+	testFolder := "txn_commit"
+	defer os.RemoveAll(testFolder)
+	t := createIntTreeStorage(2, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, testFolder)
+
+	tx := t.Begin()
+	for i := 10; i < 30; i++ {
+		if err := tx.Insert(i); err != nil {
+			t1.Fatalf("Insert(%d) error = %v", i, err)
+		}
+	}
+	for _, k := range []int{1, 5, 20} {
+		if err := tx.Delete(k); err != nil {
+			t1.Fatalf("Delete(%d) error = %v", k, err)
+		}
+	}
+	// failed operation doesn't change transaction
+	if err := tx.Delete(100); err == nil {
+		t1.Errorf("Delete(100) expected error")
+	}
+
+	// changes are visible only inside transaction
+	if ok, _ := tx.Exists(15); !ok {
+		t1.Errorf("Txn.Exists(15) got = false, want true")
+	}
+	if ok, _ := t.Exists(15); ok {
+		t1.Errorf("Exists(15) got = true before commit, want false")
+	}
+	if ok, _ := t.Exists(5); !ok {
+		t1.Errorf("Exists(5) got = false before commit, want true")
+	}
+	if tx.Len() != 26 || t.Len() != 9 {
+		t1.Errorf("Len() got = %v, %v, want 26, 9", tx.Len(), t.Len())
+	}
+
+	if err := tx.Commit(); err != nil {
+		t1.Fatalf("Commit() error = %v", err)
+	}
+	if err := tx.Insert(100); !errors.Is(err, ErrTxnDone) {
+		t1.Errorf("Insert() after Commit() error = %v, want ErrTxnDone", err)
+	}
+
+	t, _ = Open[int](testFolder, 2)
+	if t.Len() != 26 {
+		t1.Errorf("Len() got = %v, want 26", t.Len())
+	}
+	for _, k := range []int{1, 5, 20} {
+		if ok, _ := t.Exists(k); ok {
+			t1.Errorf("Exists(%d) got = true, want false", k)
+		}
+	}
+	if ok, _ := t.Exists(29); !ok {
+		t1.Errorf("Exists(29) got = false, want true")
+	}
+	checkTreeBalance(t, t1)
+}
+
+func TestTxn_Rollback(t1 *testing.T) {
+	fileName := "txn_rollback.db"
+	defer os.Remove(fileName)
+
+	s, _ := NewPagedFileStorage[int](fileName, 2)
+	defer s.Close()
+	t, _ := NewTree[int](2, s)
+
+	tx := t.Begin()
+	for i := 0; i < 50; i++ {
+		tx.Insert(i)
+	}
+	if err := tx.Rollback(); err != nil {
+		t1.Fatalf("Rollback() error = %v", err)
+	}
+	if t.Len() != 0 || t.NodeCount() != 1 {
+		t1.Errorf("Len(), NodeCount() got = %v, %v, want 0, 1", t.Len(), t.NodeCount())
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrTxnDone) {
+		t1.Errorf("Commit() after Rollback() error = %v, want ErrTxnDone", err)
+	}
+
+	// pages allocated by transaction are free again
+	pages := s.PageCount()
+	for i := 0; i < 50; i++ {
+		t.Insert(i)
+	}
+	if s.PageCount() != pages {
+		t1.Errorf("PageCount() got = %v, want %v", s.PageCount(), pages)
+	}
+	checkTreeBalance(t, t1)
+}
+
+func TestTxn_conflict(t1 *testing.T) {
+	s, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, s)
+
+	tx := t.Begin()
+	tx.Insert(1)
+	t.Insert(2)
+
+	if err := tx.Commit(); !errors.Is(err, ErrTxnConflict) {
+		t1.Errorf("Commit() error = %v, want ErrTxnConflict", err)
+	}
+	if ok, _ := t.Exists(1); ok || t.Len() != 1 {
+		t1.Errorf("Exists(1), Len() got = %v, %v, want false, 1", ok, t.Len())
+	}
+}
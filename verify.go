@@ -0,0 +1,311 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// ViolationKind is a kind of problem which is found by Verify
+type ViolationKind int
+
+const (
+	// ViolationKeysOutOfOrder - keys of Node aren't ascending or aren't between keys of parent around Node
+	ViolationKeysOutOfOrder ViolationKind = iota + 1
+	// ViolationKeyCount - amount of keys is out of [t-1, 2t-1] (root can have less keys)
+	ViolationKeyCount
+	// ViolationLeafDepth - leaf isn't on the same level as other leaves
+	ViolationLeafDepth
+	// ViolationLeafFlag - leaf has children or internal Node doesn't have them
+	ViolationLeafFlag
+	// ViolationChildCount - amount of children (or amounts of keys in their subtrees) doesn't match amount of keys
+	ViolationChildCount
+	// ViolationSubtreeCount - saved amount of keys in child's subtree is wrong
+	ViolationSubtreeCount
+	// ViolationDanglingChild - child isn't found in storage
+	ViolationDanglingChild
+	// ViolationUnreadableNode - Node can't be read from storage (e.g. its file is broken)
+	ViolationUnreadableNode
+	// ViolationRepeatedNode - Node is a child of several nodes of Tree
+	ViolationRepeatedNode
+	// ViolationMeta - metadata of Tree doesn't match its nodes
+	ViolationMeta
+	// ViolationUnreferencedNode - Node is saved in storage, but isn't a part of Tree
+	ViolationUnreferencedNode
+	// ViolationValueCount - Node has values, but their amount doesn't match amount of keys
+	ViolationValueCount
+)
+
+// String - returns name of ViolationKind
+func (k ViolationKind) String() string {
+	switch k {
+	case ViolationKeysOutOfOrder:
+		return "keys out of order"
+	case ViolationKeyCount:
+		return "wrong amount of keys"
+	case ViolationLeafDepth:
+		return "wrong depth of leaf"
+	case ViolationLeafFlag:
+		return "wrong leaf flag"
+	case ViolationChildCount:
+		return "wrong amount of children"
+	case ViolationSubtreeCount:
+		return "wrong amount of keys in subtree"
+	case ViolationDanglingChild:
+		return "dangling child"
+	case ViolationUnreadableNode:
+		return "unreadable node"
+	case ViolationRepeatedNode:
+		return "repeated node"
+	case ViolationMeta:
+		return "wrong metadata"
+	case ViolationUnreferencedNode:
+		return "unreferenced node"
+	case ViolationValueCount:
+		return "wrong amount of values"
+	}
+
+	return "unknown violation"
+}
+
+// Violation is the structure of problem which is found by Verify.
+// Kind is a kind of problem, Node is a name of Node with problem and Message describes problem
+type Violation struct {
+	Kind    ViolationKind
+	Node    string
+	Message string
+}
+
+// String - returns description of Violation
+func (v Violation) String() string {
+	return fmt.Sprintf("%s in node %s: %s", v.Kind, v.Node, v.Message)
+}
+
+// VerifyReport is the structure of result of Verify.
+// Nodes is an amount of checked nodes and Violations are all found problems
+type VerifyReport struct {
+	Nodes      int
+	Violations []Violation
+}
+
+// OK - returns true if no problem was found
+func (r VerifyReport) OK() bool {
+	return len(r.Violations) == 0
+}
+
+// nodeLister - internal interface of storage which can return names of all saved nodes.
+// Storage which wraps other storage returns errors.ErrUnsupported if wrapped storage can't list its nodes
+type nodeLister interface {
+	nodeNames() ([]string, error)
+}
+
+// verifier - internal structure of walking through Tree by Verify
+type verifier[V any] struct {
+	tree      *Tree[V]
+	report    VerifyReport
+	visited   map[string]bool
+	leafDepth int
+	len       int
+}
+
+// Verify is a function for checking Tree and its storage. Every Node is read and all found problems are reported:
+// keys out of order, amounts of keys out of [t-1, 2t-1], leaves on different levels, wrong leaf flags,
+// amounts of children which don't match amounts of keys, dangling children and metadata which doesn't match nodes.
+// If storage can list its nodes (DiskStorage, MemoryStorage, PagedFileStorage and WALStorage or CachedStorage
+// which wrap them), nodes which aren't a part of Tree are reported too. Other storages aren't checked for such nodes.
+// Nodes of clones aren't a part of Tree: clones should be passed, so their nodes aren't reported.
+// Old versions of nodes which are kept for snapshots aren't reported.
+// Error is returned only if storage can't be checked, problems of nodes are violations of report
+// - param clones are clones of Tree which are kept in the same storage
+func (t *Tree[V]) Verify(clones ...*Tree[V]) (VerifyReport, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	v := &verifier[V]{
+		tree:      t,
+		visited:   make(map[string]bool),
+		leafDepth: -1,
+	}
+
+	size, complete := v.walk(t.root, 1, nil, nil)
+	v.len += size
+	if complete {
+		v.checkMeta()
+	}
+
+	nl, ok := t.storage.(nodeLister)
+	if !ok {
+		return v.report, nil
+	}
+	names, err := nl.nodeNames()
+	if errors.Is(err, errors.ErrUnsupported) {
+		return v.report, nil
+	}
+	if err != nil {
+		return v.report, err
+	}
+
+	for _, c := range clones {
+		if c == t {
+			continue
+		}
+		if err = c.reachable(v.visited); err != nil {
+			return v.report, err
+		}
+	}
+	if err = t.pinned(v.visited); err != nil {
+		return v.report, err
+	}
+
+	slices.Sort(names)
+	for _, name := range names {
+		if !v.visited[name] {
+			v.add(ViolationUnreferencedNode, name, "node is saved in storage, but isn't a part of tree")
+		}
+	}
+
+	return v.report, nil
+}
+
+// walk - internal function for checking subtree of Node with name which is on level depth.
+// Keys of subtree should be between lo and hi (nil means unbounded). Returns amount of keys in subtree
+// and a sign that all nodes of subtree were read
+func (v *verifier[V]) walk(name string, depth int, lo, hi *V) (int, bool) {
+	t := v.tree
+	if v.visited[name] {
+		v.add(ViolationRepeatedNode, name, "node is a child of several nodes")
+		return 0, false
+	}
+	v.visited[name] = true
+
+	n, err := t.storage.Read(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			v.add(ViolationDanglingChild, name, "node isn't found in storage")
+		} else {
+			v.add(ViolationUnreadableNode, name, err.Error())
+		}
+		return 0, false
+	}
+	v.report.Nodes++
+
+	if len(n.Keys) > t.maxKeysLength() || name != t.root && len(n.Keys) < t.t-1 || name == t.root && !n.Leaf && len(n.Keys) == 0 {
+		v.add(ViolationKeyCount, name, fmt.Sprintf("node has %d keys, want from %d to %d", len(n.Keys), t.t-1, t.maxKeysLength()))
+	}
+
+	for i, k := range n.Keys {
+		if i > 0 && t.compare(n.Keys[i-1], k) >= 0 || lo != nil && t.compare(k, *lo) <= 0 || hi != nil && t.compare(k, *hi) >= 0 {
+			v.add(ViolationKeysOutOfOrder, name, fmt.Sprintf("key %v on position %d is out of order", k, i))
+			break
+		}
+	}
+
+	if n.Values != nil && len(n.Values) != len(n.Keys) {
+		v.add(ViolationValueCount, name, fmt.Sprintf("node has %d keys and %d values", len(n.Keys), len(n.Values)))
+	}
+
+	if n.Leaf {
+		if len(n.Children) > 0 {
+			v.add(ViolationLeafFlag, name, fmt.Sprintf("leaf has %d children", len(n.Children)))
+		}
+		if v.leafDepth == -1 {
+			v.leafDepth = depth
+		} else if depth != v.leafDepth {
+			v.add(ViolationLeafDepth, name, fmt.Sprintf("leaf is on level %d, other leaves are on level %d", depth, v.leafDepth))
+		}
+		return len(n.Keys), true
+	}
+
+	if len(n.Children) == 0 {
+		v.add(ViolationLeafFlag, name, "internal node doesn't have children")
+		return len(n.Keys), true
+	}
+	complete := true
+	if len(n.Children) != len(n.Keys)+1 || len(n.Counts) != len(n.Children) {
+		v.add(ViolationChildCount, name, fmt.Sprintf("node has %d keys, %d children and %d amounts of keys in subtrees",
+			len(n.Keys), len(n.Children), len(n.Counts)))
+		complete = false
+	}
+
+	size := len(n.Keys)
+	for i, c := range n.Children {
+		var clo, chi *V
+		if i > 0 && i-1 < len(n.Keys) {
+			clo = &n.Keys[i-1]
+		} else {
+			clo = lo
+		}
+		if i < len(n.Keys) {
+			chi = &n.Keys[i]
+		} else {
+			chi = hi
+		}
+
+		count, ok := v.walk(c, depth+1, clo, chi)
+		if !ok {
+			complete = false
+			continue
+		}
+		if i < len(n.Counts) && n.Counts[i] != count {
+			v.add(ViolationSubtreeCount, name, fmt.Sprintf("child %s has %d keys in subtree, but %d is saved", c, count, n.Counts[i]))
+		}
+		size += count
+	}
+
+	return size, complete
+}
+
+// checkMeta - internal function for comparing metadata of Tree with checked nodes
+func (v *verifier[V]) checkMeta() {
+	m := v.tree.meta
+	if m.Len != v.len || m.Height != v.leafDepth || m.NodeCount != v.report.Nodes {
+		v.add(ViolationMeta, v.tree.root, fmt.Sprintf("metadata is %+v, but tree has %d keys, %d levels and %d nodes",
+			m, v.len, v.leafDepth, v.report.Nodes))
+	}
+}
+
+// add - internal function for adding Violation to report
+func (v *verifier[V]) add(kind ViolationKind, name, message string) {
+	v.report.Violations = append(v.report.Violations, Violation{Kind: kind, Node: name, Message: message})
+}
+
+// reachable - internal function for adding names of all nodes of Tree to names
+func (t *Tree[V]) reachable(names map[string]bool) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return subtreeNames(t.storage, names, []string{t.root})
+}
+
+// pinned - internal function for adding names of all nodes which snapshots of Tree can see to names
+func (t *Tree[V]) pinned(names map[string]bool) error {
+	t.refs.mu.Lock()
+	defer t.refs.mu.Unlock()
+
+	roots := make([]string, 0, len(t.refs.pins))
+	for name := range t.refs.pins {
+		roots = append(roots, name)
+	}
+
+	return subtreeNames(t.storage, names, roots)
+}
+
+// subtreeNames - internal function for adding names of all nodes of subtrees with roots to names.
+// Nodes which are already in names aren't read again
+func subtreeNames[V any](s NodeStorage[V], names map[string]bool, roots []string) error {
+	for queue := roots; len(queue) > 0; queue = queue[1:] {
+		if names[queue[0]] {
+			continue
+		}
+		names[queue[0]] = true
+
+		n, err := s.Read(queue[0])
+		if err != nil {
+			return err
+		}
+		queue = append(queue, n.Children...)
+	}
+
+	return nil
+}
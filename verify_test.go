@@ -0,0 +1,262 @@
+package btree
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestTree_Verify(t1 *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(ms *MemoryStorage[int])
+		want    ViolationKind
+	}{
+		{
+			name: "keys out of order",
+			corrupt: func(ms *MemoryStorage[int]) {
+				n := ms.nodes[leftmostLeaf(ms)]
+				n.Keys[0], n.Keys[1] = n.Keys[1], n.Keys[0]
+			},
+			want: ViolationKeysOutOfOrder,
+		},
+		{
+			name: "key out of parent's range",
+			corrupt: func(ms *MemoryStorage[int]) {
+				n := ms.nodes[leftmostLeaf(ms)]
+				n.Keys[len(n.Keys)-1] = 1000
+			},
+			want: ViolationKeysOutOfOrder,
+		},
+		{
+			name: "too few keys",
+			corrupt: func(ms *MemoryStorage[int]) {
+				n := ms.nodes[leftmostLeaf(ms)]
+				n.Keys = n.Keys[:1]
+			},
+			want: ViolationKeyCount,
+		},
+		{
+			name: "leaf on other level",
+			corrupt: func(ms *MemoryStorage[int]) {
+				n := NewNode[int](3, "1000")
+				n.Keys = append(n.Keys, -2, -1)
+				ms.nodes[n.Name] = n
+				ms.nodes[RootName].Children[0] = n.Name
+			},
+			want: ViolationLeafDepth,
+		},
+		{
+			name: "wrong leaf flag",
+			corrupt: func(ms *MemoryStorage[int]) {
+				ms.nodes[leftmostLeaf(ms)].Leaf = false
+			},
+			want: ViolationLeafFlag,
+		},
+		{
+			name: "missing child",
+			corrupt: func(ms *MemoryStorage[int]) {
+				n := ms.nodes[RootName]
+				n.Children = n.Children[:len(n.Children)-1]
+			},
+			want: ViolationChildCount,
+		},
+		{
+			name: "wrong amount of keys in subtree",
+			corrupt: func(ms *MemoryStorage[int]) {
+				ms.nodes[RootName].Counts[0]++
+			},
+			want: ViolationSubtreeCount,
+		},
+		{
+			name: "dangling child",
+			corrupt: func(ms *MemoryStorage[int]) {
+				delete(ms.nodes, leftmostLeaf(ms))
+			},
+			want: ViolationDanglingChild,
+		},
+		{
+			name: "repeated node",
+			corrupt: func(ms *MemoryStorage[int]) {
+				n := ms.nodes[RootName]
+				n.Children[1] = n.Children[0]
+			},
+			want: ViolationRepeatedNode,
+		},
+		{
+			name: "wrong amount of values",
+			corrupt: func(ms *MemoryStorage[int]) {
+				ms.nodes[leftmostLeaf(ms)].Values = [][]byte{nil}
+			},
+			want: ViolationValueCount,
+		},
+		{
+			name: "unreferenced node",
+			corrupt: func(ms *MemoryStorage[int]) {
+				ms.nodes["1000"] = NewNode[int](3, "1000")
+			},
+			want: ViolationUnreferencedNode,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			ms, _ := NewMemoryStorage[int]("memory", 3)
+			t, _ := NewTree[int](3, ms)
+			for i := 0; i < 200; i++ {
+				t.Insert(i)
+			}
+
+			report, err := t.Verify()
+			if err != nil || !report.OK() || report.Nodes != t.NodeCount() {
+				t1.Fatalf("Verify() of valid tree got = %v, %v, want %v nodes without violations", report, err, t.NodeCount())
+			}
+
+			tt.corrupt(ms)
+			report, err = t.Verify()
+			if err != nil {
+				t1.Fatalf("Verify() error = %v", err)
+			}
+			if !slices.ContainsFunc(report.Violations, func(v Violation) bool { return v.Kind == tt.want }) {
+				t1.Errorf("Verify() got = %v, want violation %q", report.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_Verify_meta(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	for i := 0; i < 20; i++ {
+		t.Insert(i)
+	}
+
+	t.meta.Len++
+	report, _ := t.Verify()
+	if len(report.Violations) != 1 || report.Violations[0].Kind != ViolationMeta {
+		t1.Errorf("Verify() got = %v, want one violation %q", report.Violations, ViolationMeta)
+	}
+}
+
+func TestTree_Verify_clones(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	for i := 0; i < 50; i++ {
+		t.Insert(i)
+	}
+	c, _ := t.Clone()
+	for i := 0; i < 10; i++ {
+		c.Delete(i)
+	}
+
+	// nodes of clone aren't a part of tree
+	report, err := t.Verify()
+	if err != nil || report.OK() {
+		t1.Errorf("Verify() got = %v, %v, want violations", report, err)
+	}
+	for _, v := range report.Violations {
+		if v.Kind != ViolationUnreferencedNode {
+			t1.Errorf("Verify() got violation %v, want only %q", v, ViolationUnreferencedNode)
+		}
+	}
+
+	// tree itself can be passed among clones
+	for _, tree := range []*Tree[int]{t, c} {
+		if report, err = tree.Verify(t, c); err != nil || !report.OK() {
+			t1.Errorf("Verify() with clone got = %v, %v, want no violations", report, err)
+		}
+	}
+}
+
+func TestTree_Verify_snapshot(t1 *testing.T) {
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	t, _ := NewTree[int](2, ms)
+	for i := 0; i < 50; i++ {
+		t.Insert(i)
+	}
+
+	// old versions of nodes which are kept for snapshot aren't reported
+	s, _ := t.Snapshot()
+	for i := 0; i < 50; i += 2 {
+		t.Delete(i)
+	}
+	if len(ms.nodes) == t.NodeCount() {
+		t1.Fatalf("storage doesn't keep old versions of nodes")
+	}
+	if report, err := t.Verify(); err != nil || !report.OK() {
+		t1.Errorf("Verify() with snapshot got = %v, %v, want no violations", report, err)
+	}
+
+	s.Release()
+	if report, err := t.Verify(); err != nil || !report.OK() {
+		t1.Errorf("Verify() after Release() got = %v, %v, want no violations", report, err)
+	}
+}
+
+func TestTree_Verify_storages(t1 *testing.T) {
+	defer os.Remove("verify_paged.db")
+	defer os.Remove("verify_wal.db")
+	defer os.Remove("verify_wal.log")
+
+	tests := []struct {
+		name    string
+		storage func() NodeStorage[int]
+	}{
+		{
+			name: "paged",
+			storage: func() NodeStorage[int] {
+				s, _ := NewPagedFileStorage[int]("verify_paged.db", 2)
+				return s
+			},
+		},
+		{
+			name: "wal",
+			storage: func() NodeStorage[int] {
+				ps, _ := NewPagedFileStorage[int]("verify_wal.db", 2)
+				s, _ := OpenWALStorage[int](ps, "verify_wal.log", WALOptions[int]{})
+				return s
+			},
+		},
+		{
+			name: "cached",
+			storage: func() NodeStorage[int] {
+				ms, _ := NewMemoryStorage[int]("memory", 2)
+				s, _ := NewCachedStorage[int](ms, CacheOptions{Mode: WriteBack})
+				return s
+			},
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			s := tt.storage()
+			t, _ := NewTree[int](2, s)
+			for i := 0; i < 30; i++ {
+				t.Insert(i)
+			}
+			if report, err := t.Verify(); err != nil || !report.OK() {
+				t1.Fatalf("Verify() got = %v, %v, want no violations", report, err)
+			}
+
+			// Node which isn't a part of tree is reported
+			orphan, _ := s.AllocName()
+			n := NewNode[int](2, orphan)
+			n.Keys = append(n.Keys, 1000)
+			s.Write(n)
+			report, err := t.Verify()
+			if err != nil || !slices.ContainsFunc(report.Violations, func(v Violation) bool {
+				return v.Kind == ViolationUnreferencedNode && v.Node == orphan
+			}) {
+				t1.Errorf("Verify() got = %v, %v, want unreferenced node %v", report, err, orphan)
+			}
+		})
+	}
+}
+
+// leftmostLeaf - returns name of the leftmost leaf of tree in ms
+func leftmostLeaf(ms *MemoryStorage[int]) string {
+	n := ms.nodes[RootName]
+	for !n.Leaf {
+		n = ms.nodes[n.Children[0]]
+	}
+
+	return n.Name
+}
@@ -0,0 +1,506 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultCheckpointSize - is a size of log after which WALStorage makes checkpoint if other size isn't set
+const DefaultCheckpointSize = 1 << 20
+
+// ErrWALFailed - error of using WALStorage after logged changes weren't applied to wrapped storage.
+// Wrapped storage can be half-changed, so WALStorage should be opened again: logged changes are applied on opening
+var ErrWALFailed = errors.New("logged changes weren't applied to wrapped storage, log should be opened again")
+
+// walMagic - is a mark of WALStorage's log saved in the beginning of file
+var walMagic = [8]byte{'B', 'T', 'R', 'E', 'E', 'W', 'L', '1'}
+
+// types of log records. Records of one Batch are finished by walCommit record.
+// Names allocated before Batch are saved as walAlloc records of this Batch, its operations are saved as walOp records
+const (
+	walAlloc byte = iota + 1
+	walWrite
+	walDelete
+	walMeta
+	walCommit
+	walOp
+)
+
+// walRecordHeaderSize - size of record's header: length of payload and crc32 of type and payload
+const walRecordHeaderSize = 4 + 4
+
+// WALOptions is the structure of WALStorage's parameters.
+// Codec is an encoding of nodes and keys in log (JSONCodec if it's nil)
+// CheckpointSize is a size of log after which checkpoint is made (DefaultCheckpointSize if it's zero).
+// If it's negative, checkpoint is made only by Checkpoint function
+type WALOptions[V any] struct {
+	Codec          Codec[V]
+	CheckpointSize int64
+}
+
+// WALStorage - is a storage which saves every change to append-only log before it reaches wrapped storage.
+// Every Batch is saved as records of allocated names, operations (Insert, Delete, Put), written nodes, deleted nodes
+// and metadata finished by commit record.
+// Every record has crc32 checksum. Opening of storage replays committed batches from log and discards incomplete ones.
+// Checkpoint syncs wrapped storage and truncates log
+type WALStorage[V any] struct {
+	inner          NodeStorage[V]
+	logName        string
+	codec          Codec[V]
+	checkpointSize int64
+
+	mu         sync.Mutex
+	file       *os.File
+	headerSize int64
+	size       int64       // size of log
+	allocs     []string    // names which were allocated after the last record, they are saved with the next Batch
+	failed     atomic.Bool // logged changes weren't applied to wrapped storage, every next call returns ErrWALFailed
+}
+
+// walBatch - internal structure of Batch read from log together with names which were allocated before it
+type walBatch[V any] struct {
+	Batch[V]
+	allocs []string
+}
+
+// nameReserver - internal interface of storage which can allocate name again when log is replayed after a crash,
+// so name of restored Node isn't allocated for other Node
+type nameReserver interface {
+	reserveName(name string) error
+}
+
+// OpenWALStorage - function for opening of WALStorage. If log exists, committed batches from it are applied
+// to wrapped storage and log is truncated
+// - param inner is a wrapped storage
+// - param logName is a name of log file
+// - param opts is a codec of log and size of log for checkpoint
+func OpenWALStorage[V any](inner NodeStorage[V], logName string, opts WALOptions[V]) (*WALStorage[V], error) {
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec[V]{}
+	}
+	if opts.CheckpointSize == 0 {
+		opts.CheckpointSize = DefaultCheckpointSize
+	}
+
+	file, err := os.OpenFile(logName, os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &WALStorage[V]{
+		inner:          inner,
+		logName:        logName,
+		codec:          opts.Codec,
+		checkpointSize: opts.CheckpointSize,
+		file:           file,
+	}
+
+	if err = ws.recover(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("can't recover log %s: %w", logName, err)
+	}
+
+	return ws, nil
+}
+
+// Name - this function returns name of wrapped storage
+func (ws *WALStorage[V]) Name() string {
+	return ws.inner.Name()
+}
+
+// Close - function for closing log of WALStorage. Wrapped storage isn't closed
+func (ws *WALStorage[V]) Close() error {
+	return ws.file.Close()
+}
+
+// AllocName - function for allocating name for new Node in wrapped storage.
+// Allocation is saved to log together with the next Batch, so it's allocated again if Batch is replayed after a crash
+func (ws *WALStorage[V]) AllocName() (string, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.failed.Load() {
+		return "", ErrWALFailed
+	}
+	name, err := ws.inner.AllocName()
+	if err != nil {
+		return "", err
+	}
+	ws.allocs = append(ws.allocs, name)
+
+	return name, nil
+}
+
+// Read - function for reading Node from wrapped storage
+func (ws *WALStorage[V]) Read(name string) (*Node[V], error) {
+	if ws.failed.Load() {
+		return nil, ErrWALFailed
+	}
+
+	return ws.inner.Read(name)
+}
+
+// Write - function for saving Node to log and writing it to wrapped storage
+func (ws *WALStorage[V]) Write(n *Node[V]) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if err := ws.log(Batch[V]{Writes: []*Node[V]{n}}); err != nil {
+		return err
+	}
+
+	return ws.apply(func() error {
+		return ws.inner.Write(n)
+	})
+}
+
+// Delete - function for saving deleting of Node to log and deleting it from wrapped storage
+func (ws *WALStorage[V]) Delete(name string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if err := ws.log(Batch[V]{Deletes: []string{name}}); err != nil {
+		return err
+	}
+
+	return ws.apply(func() error {
+		return ws.inner.Delete(name)
+	})
+}
+
+// Apply - function for saving Batch to log and applying it to wrapped storage.
+// If applying is interrupted or fails, Batch is applied again on the next opening of WALStorage
+func (ws *WALStorage[V]) Apply(b Batch[V]) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if err := ws.log(b); err != nil {
+		return err
+	}
+
+	err := ws.apply(func() error {
+		return writeBatch(ws.inner, b)
+	})
+	if err != nil {
+		return err
+	}
+
+	if ws.checkpointSize > 0 && ws.size >= ws.checkpointSize {
+		return ws.checkpoint()
+	}
+
+	return nil
+}
+
+// ReadMeta - function for reading Tree's metadata from wrapped storage
+func (ws *WALStorage[V]) ReadMeta() (Meta, error) {
+	if ws.failed.Load() {
+		return Meta{}, ErrWALFailed
+	}
+	if ms, ok := ws.inner.(MetaStorage[V]); ok {
+		return ms.ReadMeta()
+	}
+
+	return Meta{}, fs.ErrNotExist
+}
+
+// WriteMeta - function for saving Tree's metadata to log and writing it to wrapped storage
+func (ws *WALStorage[V]) WriteMeta(m Meta) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if err := ws.log(Batch[V]{Meta: &m}); err != nil {
+		return err
+	}
+
+	if ms, ok := ws.inner.(MetaStorage[V]); ok {
+		return ws.apply(func() error {
+			return ms.WriteMeta(m)
+		})
+	}
+
+	return nil
+}
+
+// Manifest - function for reading parameters of Tree from wrapped storage
+func (ws *WALStorage[V]) Manifest() (Manifest, error) {
+	if ms, ok := ws.inner.(ManifestStorage[V]); ok {
+		return ms.Manifest()
+	}
+
+	return Manifest{}, errors.ErrUnsupported
+}
+
+// nodeNames - internal function: returns names of all nodes saved in wrapped storage
+func (ws *WALStorage[V]) nodeNames() ([]string, error) {
+	if ws.failed.Load() {
+		return nil, ErrWALFailed
+	}
+	if nl, ok := ws.inner.(nodeLister); ok {
+		return nl.nodeNames()
+	}
+
+	return nil, errors.ErrUnsupported
+}
+
+// Checkpoint - function for syncing wrapped storage and truncating log
+func (ws *WALStorage[V]) Checkpoint() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.failed.Load() {
+		return ErrWALFailed
+	}
+
+	return ws.checkpoint()
+}
+
+// checkpoint - internal function for syncing wrapped storage and truncating log
+func (ws *WALStorage[V]) checkpoint() error {
+	if s, ok := ws.inner.(interface{ Sync() error }); ok {
+		if err := s.Sync(); err != nil {
+			return err
+		}
+	}
+
+	if err := ws.file.Truncate(ws.headerSize); err != nil {
+		return err
+	}
+	ws.size = ws.headerSize
+
+	return ws.file.Sync()
+}
+
+// apply - internal function for applying logged changes to wrapped storage by fn. If fn fails, wrapped storage
+// can be half-changed: storage refuses all next calls until it's opened again and changes are replayed from log
+func (ws *WALStorage[V]) apply(fn func() error) error {
+	if err := fn(); err != nil {
+		ws.failed.Store(true)
+		return fmt.Errorf("%w: %w", ErrWALFailed, err)
+	}
+
+	return nil
+}
+
+// log - internal function for appending records of Batch (and names allocated before it) to log and syncing it.
+// Nothing is logged after logged changes weren't applied
+func (ws *WALStorage[V]) log(b Batch[V]) error {
+	if ws.failed.Load() {
+		return ErrWALFailed
+	}
+
+	data, err := ws.encodeBatch(b)
+	if err != nil {
+		return err
+	}
+
+	if _, err = ws.file.WriteAt(data, ws.size); err != nil {
+		return err
+	}
+	if err = ws.file.Sync(); err != nil {
+		return err
+	}
+	ws.size += int64(len(data))
+	ws.allocs = ws.allocs[:0]
+
+	return nil
+}
+
+// recover - internal function: checks header of log, applies committed batches to wrapped storage
+// and truncates log. New log gets header
+func (ws *WALStorage[V]) recover() error {
+	data, err := io.ReadAll(ws.file)
+	if err != nil {
+		return err
+	}
+
+	header := append(walMagic[:], appendBinaryBytes(nil, []byte(ws.codec.Name()))...)
+	ws.headerSize = int64(len(header))
+	if len(data) < len(walMagic) && bytes.HasPrefix(walMagic[:], data) {
+		// log is new or its header was torn by a crash while log was created: it doesn't have records
+		return ws.reset(header)
+	}
+
+	if !bytes.HasPrefix(data, walMagic[:]) {
+		return errors.New("file isn't a log of tree")
+	}
+	r := &binaryReader{data: data[len(walMagic):]}
+	codec := r.bytes()
+	if r.err != nil {
+		return ws.reset(header)
+	}
+	if string(codec) != ws.codec.Name() {
+		return fmt.Errorf("log is saved by codec %s, but %s was requested", codec, ws.codec.Name())
+	}
+
+	for _, b := range ws.decodeBatches(data[len(header):]) {
+		if err = ws.reserve(b.allocs); err != nil {
+			return err
+		}
+		if err = writeBatch(ws.inner, b.Batch); err != nil {
+			return err
+		}
+	}
+
+	return ws.checkpoint()
+}
+
+// reset - internal function for truncating log and writing its header
+func (ws *WALStorage[V]) reset(header []byte) error {
+	if err := ws.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := ws.file.WriteAt(header, 0); err != nil {
+		return err
+	}
+	ws.size = ws.headerSize
+
+	return ws.file.Sync()
+}
+
+// reserve - internal function for allocating names from log again in wrapped storage (if it can do it)
+func (ws *WALStorage[V]) reserve(names []string) error {
+	nr, ok := ws.inner.(nameReserver)
+	if !ok {
+		return nil
+	}
+
+	for _, name := range names {
+		if err := nr.reserveName(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeBatch - internal function for encoding Batch and names allocated before it to log records.
+// Key and value of operation are encoded by codec as Node with one key
+func (ws *WALStorage[V]) encodeBatch(b Batch[V]) ([]byte, error) {
+	var buf []byte
+	for _, name := range ws.allocs {
+		buf = appendWALRecord(buf, walAlloc, []byte(name))
+	}
+
+	for _, op := range b.Ops {
+		n := &Node[V]{Keys: []V{op.Key}}
+		if op.Value != nil {
+			n.Values = [][]byte{op.Value}
+		}
+		data, err := ws.codec.Marshal(n)
+		if err != nil {
+			return nil, err
+		}
+		buf = appendWALRecord(buf, walOp, append([]byte{byte(op.Kind)}, data...))
+	}
+
+	for _, n := range b.Writes {
+		data, err := ws.codec.Marshal(n)
+		if err != nil {
+			return nil, err
+		}
+		buf = appendWALRecord(buf, walWrite, data)
+	}
+
+	for _, name := range b.Deletes {
+		buf = appendWALRecord(buf, walDelete, []byte(name))
+	}
+
+	if b.Meta != nil {
+		data := binary.AppendUvarint(nil, uint64(b.Meta.Len))
+		data = binary.AppendUvarint(data, uint64(b.Meta.Height))
+		data = binary.AppendUvarint(data, uint64(b.Meta.NodeCount))
+		buf = appendWALRecord(buf, walMeta, data)
+	}
+
+	return appendWALRecord(buf, walCommit, nil), nil
+}
+
+// decodeBatches - internal function for decoding committed batches from log records.
+// Reading stops on the first broken record: it and records of not committed Batch are discarded
+func (ws *WALStorage[V]) decodeBatches(data []byte) []walBatch[V] {
+	var batches []walBatch[V]
+	var b walBatch[V]
+	for {
+		typ, payload, rest, ok := readWALRecord(data)
+		if !ok {
+			return batches
+		}
+		data = rest
+
+		switch typ {
+		case walAlloc:
+			b.allocs = append(b.allocs, string(payload))
+		case walOp:
+			if len(payload) == 0 {
+				return batches
+			}
+			n, err := ws.codec.Unmarshal(payload[1:])
+			if err != nil || len(n.Keys) != 1 {
+				return batches
+			}
+			b.Ops = append(b.Ops, Op[V]{Kind: OpKind(payload[0]), Key: n.Keys[0], Value: n.value(0)})
+		case walWrite:
+			n, err := ws.codec.Unmarshal(payload)
+			if err != nil {
+				return batches
+			}
+			b.Writes = append(b.Writes, n)
+		case walDelete:
+			b.Deletes = append(b.Deletes, string(payload))
+		case walMeta:
+			r := &binaryReader{data: payload}
+			m := Meta{Len: int(r.uvarint()), Height: int(r.uvarint()), NodeCount: int(r.uvarint())}
+			if r.err != nil {
+				return batches
+			}
+			b.Meta = &m
+		case walCommit:
+			batches = append(batches, b)
+			b = walBatch[V]{}
+		default:
+			return batches
+		}
+	}
+}
+
+// appendWALRecord - internal function: appends record with type typ and payload to buf
+func appendWALRecord(buf []byte, typ byte, payload []byte) []byte {
+	crc := crc32.NewIEEE()
+	crc.Write([]byte{typ})
+	crc.Write(payload)
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = binary.LittleEndian.AppendUint32(buf, crc.Sum32())
+	buf = append(buf, typ)
+
+	return append(buf, payload...)
+}
+
+// readWALRecord - internal function: reads the first record from data. Returns false if record is incomplete or broken
+func readWALRecord(data []byte) (typ byte, payload, rest []byte, ok bool) {
+	if len(data) < walRecordHeaderSize+1 {
+		return 0, nil, nil, false
+	}
+
+	l := binary.LittleEndian.Uint32(data)
+	sum := binary.LittleEndian.Uint32(data[4:])
+	if uint64(len(data)-walRecordHeaderSize-1) < uint64(l) {
+		return 0, nil, nil, false
+	}
+
+	record := data[walRecordHeaderSize : walRecordHeaderSize+1+int(l)]
+	if crc32.ChecksumIEEE(record) != sum {
+		return 0, nil, nil, false
+	}
+
+	return record[0], record[1:], data[walRecordHeaderSize+1+int(l):], true
+}
@@ -0,0 +1,277 @@
+package btree
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestWALStorage_Tree(t1 *testing.T) {
+	testFolder, logName := "wal_tree", "wal_tree.log"
+	defer os.RemoveAll(testFolder)
+	defer os.Remove(logName)
+
+	ds, _ := NewDiskStorage[int](testFolder, 2)
+	s, err := OpenWALStorage[int](ds, logName, WALOptions[int]{CheckpointSize: -1})
+	if err != nil {
+		t1.Fatalf("OpenWALStorage() error = %v", err)
+	}
+	t, err := NewTree[int](2, s)
+	if err != nil {
+		t1.Fatalf("NewTree() error = %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		t.Insert(i)
+	}
+	for i := 0; i < 50; i += 2 {
+		t.Delete(i)
+	}
+	checkTreeBalance(t, t1)
+
+	// log keeps every operation until checkpoint
+	batches := s.decodeBatches(s.readLog(t1))
+	if len(batches) != 75 {
+		t1.Fatalf("amount of batches in log got = %v, want 75", len(batches))
+	}
+	if m := batches[74].Meta; m == nil || m.Len != 25 {
+		t1.Errorf("metadata of the last batch got = %+v, want Len 25", m)
+	}
+	if ops := batches[74].Ops; len(ops) != 1 || ops[0].Kind != OpDelete || ops[0].Key != 48 {
+		t1.Errorf("operations of the last batch got = %+v, want delete of 48", ops)
+	}
+
+	if err := s.Checkpoint(); err != nil {
+		t1.Fatalf("Checkpoint() error = %v", err)
+	}
+	if info, _ := os.Stat(logName); info.Size() != s.headerSize {
+		t1.Errorf("log size after checkpoint got = %v, want %v", info.Size(), s.headerSize)
+	}
+
+	// checkpoint is made automatically when log becomes large
+	s.checkpointSize = 2048
+	for i := 100; i < 150; i++ {
+		t.Insert(i)
+	}
+	if info, _ := os.Stat(logName); info.Size() >= 2048 {
+		t1.Errorf("log size got = %v, want less than 2048", info.Size())
+	}
+	s.Close()
+}
+
+func TestWALStorage_recover(t1 *testing.T) {
+	testFolder, logName := "wal_recover", "wal_recover.log"
+	defer os.RemoveAll(testFolder)
+	defer os.Remove(logName)
+
+	ds, _ := NewDiskStorage[int](testFolder, 2)
+	s, _ := OpenWALStorage[int](ds, logName, WALOptions[int]{Codec: BinaryCodec[int]{}})
+	t, _ := NewTree[int](2, s)
+	for i := 0; i < 20; i++ {
+		t.Insert(i)
+	}
+
+	// crash after Batch was saved to log, but before it was applied: Batch is applied on opening
+	b := collectBatch(t, func(t *Tree[int]) error {
+		return t.insert(100, nil)
+	})
+	if err := s.log(b); err != nil {
+		t1.Fatalf("log() error = %v", err)
+	}
+
+	// crash while Batch was saved to log: incomplete Batch is discarded
+	b = collectBatch(t, func(t *Tree[int]) error {
+		_, err := t.delete(5)
+		return err
+	})
+	s.log(b)
+	s.Close()
+	info, _ := os.Stat(logName)
+	os.Truncate(logName, info.Size()-3)
+
+	ds, _ = OpenDiskStorage[int](testFolder, 2)
+	s, err := OpenWALStorage[int](ds, logName, WALOptions[int]{Codec: BinaryCodec[int]{}})
+	if err != nil {
+		t1.Fatalf("OpenWALStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	t, _ = NewTree[int](2, s)
+	if t.Len() != 21 {
+		t1.Errorf("Len() got = %v, want 21", t.Len())
+	}
+	if ok, _ := t.Exists(100); !ok {
+		t1.Errorf("Exists(100) got = false, want true")
+	}
+	if ok, _ := t.Exists(5); !ok {
+		t1.Errorf("Exists(5) got = false, want true")
+	}
+	checkTreeBalance(t, t1)
+
+	// name of restored Node isn't allocated again
+	for i := 200; i < 300; i++ {
+		t.Insert(i)
+	}
+	checkTreeBalance(t, t1)
+
+	if _, err := OpenWALStorage[int](ds, logName, WALOptions[int]{}); err == nil {
+		t1.Errorf("OpenWALStorage() with another codec expected error")
+	}
+}
+
+func TestWALStorage_recover_PagedFileStorage(t1 *testing.T) {
+	fileName, logName := "wal_paged.db", "wal_paged.log"
+	defer os.Remove(fileName)
+	defer os.Remove(logName)
+
+	ps, _ := NewPagedFileStorage[int](fileName, 2)
+	s, _ := OpenWALStorage[int](ps, logName, WALOptions[int]{})
+	t, _ := NewTree[int](2, s)
+	for i := 0; i < 30; i++ {
+		t.Insert(i)
+	}
+	for i := 0; i < 10; i++ {
+		t.Delete(i)
+	}
+	pages := ps.PageCount()
+
+	// crash after Batch was saved to log: pages allocated for it (from free list and new ones)
+	// are allocated again on opening, so new nodes are written to them
+	b := collectBatch(t, func(t *Tree[int]) error {
+		for k := 100; k < 130; k++ {
+			if err := t.insert(k, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err := s.log(b); err != nil {
+		t1.Fatalf("log() error = %v", err)
+	}
+	s.Close()
+	ps.Close()
+
+	ps, err := OpenPagedFileStorage[int](fileName, 2)
+	if err != nil {
+		t1.Fatalf("OpenPagedFileStorage() error = %v", err)
+	}
+	defer ps.Close()
+	if ps.PageCount() != pages {
+		t1.Fatalf("PageCount() before recovery got = %v, want %v", ps.PageCount(), pages)
+	}
+	s, err = OpenWALStorage[int](ps, logName, WALOptions[int]{})
+	if err != nil {
+		t1.Fatalf("OpenWALStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	t, _ = NewTree[int](2, s)
+	for k := 100; k < 130; k++ {
+		if ok, err := t.Exists(k); !ok || err != nil {
+			t1.Errorf("Exists(%d) got = %v, %v, want true", k, ok, err)
+		}
+	}
+
+	// allocated pages aren't allocated again
+	for k := 200; k < 300; k++ {
+		t.Insert(k)
+	}
+	checkTreeBalance(t, t1)
+}
+
+func TestWALStorage_torn_header(t1 *testing.T) {
+	logName := "wal_torn_header.log"
+	defer os.Remove(logName)
+
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	for _, l := range []int{3, 10} {
+		// crash while header of new log was written: log is truncated and gets header again
+		header := append(walMagic[:], appendBinaryBytes(nil, []byte(JSONCodec[int]{}.Name()))...)
+		os.WriteFile(logName, header[:l], 0o644)
+
+		s, err := OpenWALStorage[int](ms, logName, WALOptions[int]{})
+		if err != nil {
+			t1.Fatalf("OpenWALStorage() of log with %d bytes error = %v", l, err)
+		}
+		if info, _ := os.Stat(logName); info.Size() != s.headerSize {
+			t1.Errorf("log size got = %v, want %v", info.Size(), s.headerSize)
+		}
+		s.Close()
+	}
+}
+
+func TestWALStorage_inner_failed(t1 *testing.T) {
+	logName := "wal_inner_failed.log"
+	defer os.Remove(logName)
+
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	ts := &testStorage{MemoryStorage: ms}
+	s, _ := OpenWALStorage[int](ts, logName, WALOptions[int]{CheckpointSize: -1})
+	t, _ := NewTree[int](2, s)
+	for i := 0; i < 100; i++ {
+		t.Insert(i)
+	}
+
+	// wrapped storage fails in the middle of logged batch: storage refuses all next calls
+	ts.writes, ts.failWriteAfter = 0, 2
+	if _, err := t.InsertMany(makeRange(100, 200)); !errors.Is(err, ErrWALFailed) {
+		t1.Fatalf("InsertMany() error = %v, want ErrWALFailed", err)
+	}
+	ts.failWriteAfter = 0
+	if err := t.Insert(500); !errors.Is(err, ErrWALFailed) {
+		t1.Errorf("Insert() after failed batch error = %v, want ErrWALFailed", err)
+	}
+	if _, err := t.Exists(1); !errors.Is(err, ErrWALFailed) {
+		t1.Errorf("Exists() after failed batch error = %v, want ErrWALFailed", err)
+	}
+	if err := s.Checkpoint(); !errors.Is(err, ErrWALFailed) {
+		t1.Errorf("Checkpoint() after failed batch error = %v, want ErrWALFailed", err)
+	}
+	s.Close()
+
+	// logged batch is applied on opening
+	s, err := OpenWALStorage[int](ts, logName, WALOptions[int]{})
+	if err != nil {
+		t1.Fatalf("OpenWALStorage() error = %v", err)
+	}
+	defer s.Close()
+	t, _ = NewTree[int](2, s)
+	if report, err := t.Verify(); err != nil || !report.OK() {
+		t1.Errorf("Verify() got = %v, %v, want no violations", report, err)
+	}
+	if got := collectKeys(t.All()); !slices.Equal(got, makeRange(0, 200)) {
+		t1.Errorf("All() after opening got = %v", got)
+	}
+}
+
+func TestWALStorage_without_manifest(t1 *testing.T) {
+	logName := "wal_memory.log"
+	defer os.Remove(logName)
+
+	ms, _ := NewMemoryStorage[int]("memory", 2)
+	s, err := OpenWALStorage[int](plainStorage{ms}, logName, WALOptions[int]{})
+	if err != nil {
+		t1.Fatalf("OpenWALStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	// storage without manifest and metadata
+	t, err := NewTree[int](2, s)
+	if err != nil {
+		t1.Fatalf("NewTree() error = %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		t.Insert(i)
+	}
+	checkTreeBalance(t, t1)
+}
+
+// readLog - returns records of log without header
+func (ws *WALStorage[V]) readLog(t1 *testing.T) []byte {
+	data, err := os.ReadFile(ws.logName)
+	if err != nil {
+		t1.Fatalf("ReadFile() error = %v", err)
+	}
+
+	return data[ws.headerSize:]
+}